@@ -7,8 +7,14 @@ import (
 	"sync"
 	"sync/atomic"
 	"time"
+
+	"github.com/theHamdiz/it/clock"
+	"github.com/theHamdiz/it/logger"
 )
 
+// Where the breaker vents, filed under "cb"
+var log = logger.Named("cb")
+
 const (
 	ErrCircuitOpen = "circuit breaker is open"
 )
@@ -21,19 +27,35 @@ type CircuitBreaker struct {
 	threshold   int64         // How many failures until we give up
 	timeout     time.Duration // How long we sulk before trying again
 	mu          sync.RWMutex  // Protects our delicate state
+	clock       clock.Clock   // Keeps time for the sulking
+}
+
+// CircuitBreakerOption adjusts a breaker before it starts judging you
+type CircuitBreakerOption func(*CircuitBreaker)
+
+// WithClock swaps the wall clock for another one, so tests can skip the sulking
+func WithClock(c clock.Clock) CircuitBreakerOption {
+	return func(cb *CircuitBreaker) {
+		cb.clock = clock.Or(c)
+	}
 }
 
 // NewCircuitBreaker creates a new failure detection system
 // threshold: how many times you're willing to get hurt
 // timeout: how long you need to recover from trust issues
-func NewCircuitBreaker(threshold int64, timeout time.Duration) *CircuitBreaker {
+func NewCircuitBreaker(threshold int64, timeout time.Duration, opts ...CircuitBreakerOption) *CircuitBreaker {
 	if threshold < 1 {
 		threshold = 1 // Because zero tolerance is too harsh
 	}
-	return &CircuitBreaker{
+	cb := &CircuitBreaker{
 		threshold: threshold,
 		timeout:   timeout,
+		clock:     clock.Real(),
+	}
+	for _, opt := range opts {
+		opt(cb)
 	}
+	return cb
 }
 
 // Execute attempts to run your probably-going-to-fail function
@@ -51,24 +73,28 @@ func (cb *CircuitBreaker) Execute(fn func() error) error {
 			return errors.New(ErrCircuitOpen)
 		}
 		// For non-zero timeout, check if enough time has passed
-		if time.Since(lastFail) <= cb.timeout {
+		if cb.clock.Since(lastFail) <= cb.timeout {
 			return errors.New(ErrCircuitOpen)
 		}
 		// Reset circuit after timeout
 		cb.mu.Lock()
 		cb.reset()
 		cb.mu.Unlock()
+		log.Debug("Circuit breaker timeout elapsed, giving it another chance")
 	}
 
 	// Execute function
 	if err := fn(); err != nil {
 		cb.mu.Lock()
 		cb.failures.Add(1)
-		cb.lastFailure.Store(time.Now().UnixNano())
+		cb.lastFailure.Store(cb.clock.Now().UnixNano())
 		currentFails := cb.failures.Load()
 		cb.mu.Unlock()
 
 		if currentFails >= cb.threshold {
+			if currentFails == cb.threshold {
+				log.Warnf("Circuit breaker opened after %d failures: %v", currentFails, err)
+			}
 			return errors.New(ErrCircuitOpen)
 		}
 		return err
@@ -86,7 +112,7 @@ func (cb *CircuitBreaker) canTry() bool {
 			return false
 		}
 		lastFail := time.Unix(0, cb.lastFailure.Load())
-		if time.Since(lastFail) <= cb.timeout {
+		if cb.clock.Since(lastFail) <= cb.timeout {
 			return false // Still in therapy
 		}
 		cb.reset()
@@ -97,7 +123,7 @@ func (cb *CircuitBreaker) canTry() bool {
 // recordFailure adds another tally to our wall of shame
 func (cb *CircuitBreaker) recordFailure() {
 	cb.failures.Add(1)
-	cb.lastFailure.Store(time.Now().UnixNano())
+	cb.lastFailure.Store(cb.clock.Now().UnixNano())
 }
 
 // reset wipes the slate clean (but not your memory)
@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"github.com/theHamdiz/it/cb"
+	"github.com/theHamdiz/it/clock"
 )
 
 var (
@@ -355,3 +356,19 @@ func BenchmarkCircuitBreaker(b *testing.B) {
 		}
 	})
 }
+
+// TestCircuitBreaker_FakeClock sulks for an hour without the test waiting one
+func TestCircuitBreaker_FakeClock(t *testing.T) {
+	fake := clock.NewFake(time.Now())
+	breaker := cb.NewCircuitBreaker(1, time.Hour, cb.WithClock(fake))
+
+	_ = breaker.Execute(func() error { return errTest })
+	if err := breaker.Execute(func() error { return nil }); err == nil {
+		t.Fatal("Expected the circuit to be open")
+	}
+
+	fake.Advance(time.Hour + time.Second)
+	if err := breaker.Execute(func() error { return nil }); err != nil {
+		t.Errorf("Expected the circuit to close after the timeout, got %v", err)
+	}
+}
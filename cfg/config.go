@@ -3,21 +3,26 @@
 package cfg
 
 import (
-	"os"
 	"time"
 
 	"github.com/fatih/color"
 	"github.com/theHamdiz/it/logger"
+	"github.com/theHamdiz/it/logger/audit"
 	"github.com/theHamdiz/it/retry"
 )
 
 // Config holds all the knobs you can tweak until your application breaks
 type Config struct {
-	LogLevel        logger.LogLevel // How much spam you want in your logs
-	LogFile         string          // Where your logs go to die
-	ShutdownTimeout time.Duration   // How long before we kill it with fire
-	RetryConfig     retry.Config    // For when at first you don't succeed
-	EnableColors    bool            // Making logs pretty won't fix your bugs
+	LogLevel        logger.LogLevel       // How much spam you want in your logs
+	LogFile         string                // Where your logs go to die
+	LogRotation     logger.RotationConfig // When your logs get put out of their misery
+	LogFormat       logger.LogFormat      // text, json, logfmt or console; empty leaves it alone
+	Redactor        *logger.Redactor      // Keeps your secrets out of your logs (mostly)
+	AuditFile       string                // Where the paper trail for legal goes
+	AuditKey        []byte                // Signs the paper trail, keep it somewhere safer than this struct
+	ShutdownTimeout time.Duration         // How long before we kill it with fire
+	RetryConfig     retry.Config          // For when at first you don't succeed
+	EnableColors    bool                  // Making logs pretty won't fix your bugs
 }
 
 // Our sensible* defaults
@@ -42,9 +47,21 @@ func Configure(opts ...ConfigOption) *Config {
 
 	// Let's actually use these settings (what could go wrong?)
 	logger.SetLogLevel(cfg.LogLevel)
+	if cfg.LogFormat != "" {
+		// Before the log file, so its handler is built with the right format
+		logger.SetLogFormat(cfg.LogFormat)
+	}
 	if cfg.LogFile != "" {
-		if file, err := os.OpenFile(cfg.LogFile, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644); err == nil {
-			logger.SetLogOutput(file)
+		// Replaces (and closes) whatever file we were writing to before
+		_, _ = logger.SetLogFile(cfg.LogFile, cfg.LogRotation)
+	}
+	if cfg.Redactor != nil {
+		logger.SetRedactor(cfg.Redactor)
+	}
+	if cfg.AuditFile != "" {
+		// Replaces (and closes) whatever audit trail we were writing before
+		if err := audit.SetFile(cfg.AuditFile, cfg.AuditKey); err != nil {
+			logger.DefaultLogger().Errorf("Audit trail unavailable: %v", err)
 		}
 	}
 	color.NoColor = !cfg.EnableColors
@@ -65,6 +82,35 @@ func WithLogFile(file string) ConfigOption {
 	}
 }
 
+// WithLogRotation - Because log files, like houseguests, shouldn't grow without bound
+func WithLogRotation(rotation logger.RotationConfig) ConfigOption {
+	return func(c *Config) {
+		c.LogRotation = rotation
+	}
+}
+
+// WithLogFormat - Pick how your logs look, since you'll be staring at them a lot
+func WithLogFormat(format logger.LogFormat) ConfigOption {
+	return func(c *Config) {
+		c.LogFormat = format
+	}
+}
+
+// WithRedactor - Because "we rotated the keys" is a sentence nobody wants to say
+func WithRedactor(redactor *logger.Redactor) ConfigOption {
+	return func(c *Config) {
+		c.Redactor = redactor
+	}
+}
+
+// WithAuditLog - Because "trust me" doesn't pass a compliance review
+func WithAuditLog(file string, key []byte) ConfigOption {
+	return func(c *Config) {
+		c.AuditFile = file
+		c.AuditKey = key
+	}
+}
+
 // WithShutdownTimeout - How patient are you really?
 func WithShutdownTimeout(timeout time.Duration) ConfigOption {
 	return func(c *Config) {
@@ -91,6 +137,16 @@ func (c *Config) GetLogLevel() logger.LogLevel {
 	return c.LogLevel
 }
 
+// GetLogRotation - When your logs get rolled, if ever
+func (c *Config) GetLogRotation() logger.RotationConfig {
+	return c.LogRotation
+}
+
+// GetLogFormat - What your logs look like, or "" if we never touched it
+func (c *Config) GetLogFormat() logger.LogFormat {
+	return c.LogFormat
+}
+
 // GetShutdownTimeout - How long until we give up and kill -9
 func (c *Config) GetShutdownTimeout() time.Duration {
 	return c.ShutdownTimeout
@@ -2,11 +2,13 @@ package cfg_test
 
 import (
 	"os"
+	"path/filepath"
 	"testing"
 	"time"
 
 	"github.com/theHamdiz/it/cfg"
 	"github.com/theHamdiz/it/logger"
+	"github.com/theHamdiz/it/logger/audit"
 )
 
 func TestConfigure_WithLogLevel(t *testing.T) {
@@ -89,3 +91,50 @@ func TestConfigure_WithMultipleOptions(t *testing.T) {
 		t.Errorf("Expected colors to be disabled, but they are enabled")
 	}
 }
+
+func TestConfigure_WithLogRotation(t *testing.T) {
+	logFile := filepath.Join(t.TempDir(), "rotated.log")
+	rotation := logger.RotationConfig{MaxSize: 1024, MaxBackups: 3, Compress: true}
+
+	cfg_ := cfg.Configure(cfg.WithLogFile(logFile), cfg.WithLogRotation(rotation))
+
+	if cfg_.GetLogRotation() != rotation {
+		t.Errorf("Expected rotation %+v, got %+v", rotation, cfg_.GetLogRotation())
+	}
+	if _, err := os.Stat(logFile); os.IsNotExist(err) {
+		t.Errorf("Expected log file %s to be created, but it was not", logFile)
+	}
+}
+
+func TestConfigure_WithRedactor(t *testing.T) {
+	redactor := logger.DefaultRedactor()
+	defer logger.SetRedactor(nil)
+
+	cfg_ := cfg.Configure(cfg.WithRedactor(redactor))
+	if cfg_.Redactor != redactor || logger.DefaultLogger().Redactor() != redactor {
+		t.Errorf("Expected redactor to be installed on the default logger")
+	}
+}
+
+func TestConfigure_WithLogFormat(t *testing.T) {
+	defer logger.SetLogFormat(logger.FormatText)
+
+	cfg_ := cfg.Configure(cfg.WithLogFormat(logger.FormatLogfmt))
+	if cfg_.GetLogFormat() != logger.FormatLogfmt || logger.DefaultLogger().Format() != logger.FormatLogfmt {
+		t.Errorf("Expected logfmt on the default logger, got %q", logger.DefaultLogger().Format())
+	}
+}
+
+func TestConfigure_WithAuditLog(t *testing.T) {
+	defer audit.SetDefault(nil)
+	path := filepath.Join(t.TempDir(), "audit.log")
+	key := []byte("s3cret")
+
+	cfg.Configure(cfg.WithAuditLog(path, key))
+	if _, err := audit.Record(audit.Event{Actor: "ops", Action: "configure", Outcome: audit.OutcomeSuccess}); err != nil {
+		t.Fatalf("Expected an audit trail to be configured, got %v", err)
+	}
+	if _, err := audit.VerifyFile(path, key); err != nil {
+		t.Errorf("Expected a verifiable trail, got %v", err)
+	}
+}
@@ -0,0 +1,109 @@
+// Package clock - Because tests that sleep for real are tests nobody runs
+package clock
+
+import (
+	"time"
+)
+
+// Clock is everything our packages ask of time. Real is the one you want in
+// production, Fake the one you want in tests.
+type Clock interface {
+	Now() time.Time
+	Since(t time.Time) time.Duration
+	Until(t time.Time) time.Duration
+	Sleep(d time.Duration)
+	After(d time.Duration) <-chan time.Time
+	NewTimer(d time.Duration) Timer
+	NewTicker(d time.Duration) Ticker
+	AfterFunc(d time.Duration, f func()) Timer
+}
+
+// Timer is a *time.Timer that doesn't insist on being one
+type Timer interface {
+	// C is nil for timers made by AfterFunc, same as the real thing
+	C() <-chan time.Time
+	Stop() bool
+	Reset(d time.Duration) bool
+}
+
+// Ticker is a *time.Ticker that doesn't insist on being one
+type Ticker interface {
+	C() <-chan time.Time
+	Stop()
+	Reset(d time.Duration)
+}
+
+// realClock hands everything to package time
+type realClock struct{}
+
+// realTimer wraps *time.Timer
+type realTimer struct {
+	*time.Timer
+}
+
+// realTicker wraps *time.Ticker
+type realTicker struct {
+	*time.Ticker
+}
+
+// Real returns the clock on the wall
+func Real() Clock {
+	return realClock{}
+}
+
+// Or returns c, or the real clock when c is nil. Handy for optional fields.
+func Or(c Clock) Clock {
+	if c == nil {
+		return realClock{}
+	}
+	return c
+}
+
+// Underlying returns the *time.Timer behind a timer from the real clock,
+// nil for anything else
+func Underlying(t Timer) *time.Timer {
+	if rt, ok := t.(realTimer); ok {
+		return rt.Timer
+	}
+	return nil
+}
+
+func (realClock) Now() time.Time {
+	return time.Now()
+}
+
+func (realClock) Since(t time.Time) time.Duration {
+	return time.Since(t)
+}
+
+func (realClock) Until(t time.Time) time.Duration {
+	return time.Until(t)
+}
+
+func (realClock) Sleep(d time.Duration) {
+	time.Sleep(d)
+}
+
+func (realClock) After(d time.Duration) <-chan time.Time {
+	return time.After(d)
+}
+
+func (realClock) NewTimer(d time.Duration) Timer {
+	return realTimer{time.NewTimer(d)}
+}
+
+func (realClock) NewTicker(d time.Duration) Ticker {
+	return realTicker{time.NewTicker(d)}
+}
+
+func (realClock) AfterFunc(d time.Duration, f func()) Timer {
+	return realTimer{time.AfterFunc(d, f)}
+}
+
+func (t realTimer) C() <-chan time.Time {
+	return t.Timer.C
+}
+
+func (t realTicker) C() <-chan time.Time {
+	return t.Ticker.C
+}
@@ -0,0 +1,157 @@
+package clock_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/theHamdiz/it/clock"
+)
+
+var epoch = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+func TestFake_Timer(t *testing.T) {
+	c := clock.NewFake(epoch)
+	timer := c.NewTimer(time.Second)
+
+	c.Advance(999 * time.Millisecond)
+	select {
+	case <-timer.C():
+		t.Fatal("Expected the timer to wait a full second")
+	default:
+	}
+
+	c.Advance(time.Millisecond)
+	select {
+	case at := <-timer.C():
+		if !at.Equal(epoch.Add(time.Second)) {
+			t.Errorf("Expected the timer to fire at its due time, got %v", at)
+		}
+	default:
+		t.Fatal("Expected the timer to fire")
+	}
+	if timer.Stop() {
+		t.Error("Expected Stop on a fired timer to report false")
+	}
+}
+
+func TestFake_TimerResetAndStop(t *testing.T) {
+	c := clock.NewFake(epoch)
+	timer := c.NewTimer(time.Second)
+	if !timer.Reset(3 * time.Second) {
+		t.Error("Expected Reset on a pending timer to report true")
+	}
+	c.Advance(2 * time.Second)
+	if len(timer.C()) != 0 {
+		t.Fatal("Expected Reset to push the timer back")
+	}
+	if !timer.Stop() || c.Pending() != 0 {
+		t.Error("Expected Stop to cancel the pending timer")
+	}
+	c.Advance(time.Hour)
+	if len(timer.C()) != 0 {
+		t.Error("Expected a stopped timer to stay quiet")
+	}
+}
+
+func TestFake_Ticker(t *testing.T) {
+	c := clock.NewFake(epoch)
+	ticker := c.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	var ticks []time.Time
+	for i := 0; i < 3; i++ {
+		c.Advance(time.Second)
+		ticks = append(ticks, <-ticker.C())
+	}
+	if !ticks[2].Equal(epoch.Add(3 * time.Second)) {
+		t.Errorf("Expected the third tick at 3s, got %v", ticks[2])
+	}
+
+	// Like time.Ticker, ticks nobody reads are dropped rather than queued
+	c.Advance(5 * time.Second)
+	if len(ticker.C()) != 1 {
+		t.Errorf("Expected a single buffered tick, got %d", len(ticker.C()))
+	}
+}
+
+func TestFake_AfterFuncOrder(t *testing.T) {
+	c := clock.NewFake(epoch)
+	var order []string
+	var seen []time.Time
+	c.AfterFunc(2*time.Second, func() { order = append(order, "two"); seen = append(seen, c.Now()) })
+	c.AfterFunc(time.Second, func() { order = append(order, "one"); seen = append(seen, c.Now()) })
+	c.AfterFunc(time.Second, func() { order = append(order, "one again") })
+
+	c.Advance(10 * time.Second)
+	if len(order) != 3 || order[0] != "one" || order[1] != "one again" || order[2] != "two" {
+		t.Errorf("Expected due-time order with ties in creation order, got %v", order)
+	}
+	if !seen[0].Equal(epoch.Add(time.Second)) || !seen[1].Equal(epoch.Add(2*time.Second)) {
+		t.Errorf("Expected Now to show each callback's due time, got %v", seen)
+	}
+	if !c.Now().Equal(epoch.Add(10 * time.Second)) {
+		t.Errorf("Expected to end at the target time, got %v", c.Now())
+	}
+}
+
+func TestFake_SleepAndBlockUntil(t *testing.T) {
+	c := clock.NewFake(epoch)
+	done := make(chan struct{})
+	go func() {
+		c.Sleep(time.Minute)
+		close(done)
+	}()
+
+	c.BlockUntil(1)
+	c.Advance(time.Minute)
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Expected the sleeper to wake up")
+	}
+}
+
+func TestWithTimeout_Fake(t *testing.T) {
+	c := clock.NewFake(epoch)
+	ctx, cancel := clock.WithTimeout(context.Background(), c, time.Second)
+	defer cancel()
+
+	if deadline, ok := ctx.Deadline(); !ok || !deadline.Equal(epoch.Add(time.Second)) {
+		t.Errorf("Expected the deadline on the fake clock, got %v", deadline)
+	}
+	c.Advance(time.Second)
+	<-ctx.Done()
+	if !errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		t.Errorf("Expected DeadlineExceeded, got %v", ctx.Err())
+	}
+}
+
+func TestWithTimeout_ParentCancel(t *testing.T) {
+	parent, cancelParent := context.WithCancel(context.Background())
+	ctx, cancel := clock.WithTimeout(parent, clock.NewFake(epoch), time.Hour)
+	defer cancel()
+
+	cancelParent()
+	select {
+	case <-ctx.Done():
+	case <-time.After(time.Second):
+		t.Fatal("Expected the parent's cancellation to reach the child")
+	}
+	if !errors.Is(ctx.Err(), context.Canceled) {
+		t.Errorf("Expected Canceled, got %v", ctx.Err())
+	}
+}
+
+func TestReal(t *testing.T) {
+	c := clock.Or(nil)
+	timer := c.NewTimer(time.Millisecond)
+	<-timer.C()
+	if clock.Underlying(timer) == nil {
+		t.Error("Expected the real clock to hand out real timers")
+	}
+	if clock.Underlying(clock.NewFake(epoch).NewTimer(time.Second)) != nil {
+		t.Error("Expected fake timers to have no *time.Timer behind them")
+	}
+}
@@ -0,0 +1,69 @@
+package clock
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// timeoutCtx is a context whose deadline is kept by a Clock instead of the wall
+type timeoutCtx struct {
+	context.Context
+	deadline time.Time
+	done     chan struct{}
+	once     sync.Once
+
+	mu  sync.Mutex
+	err error
+}
+
+// WithTimeout is context.WithTimeout on c's time. The real clock gets the
+// real thing; any other clock gets a context that expires, with
+// context.DeadlineExceeded, when that clock says so.
+func WithTimeout(parent context.Context, c Clock, d time.Duration) (context.Context, context.CancelFunc) {
+	if _, ok := Or(c).(realClock); ok {
+		return context.WithTimeout(parent, d)
+	}
+
+	ctx := &timeoutCtx{Context: parent, deadline: c.Now().Add(d), done: make(chan struct{})}
+	if err := parent.Err(); err != nil {
+		ctx.finish(err)
+		return ctx, func() {}
+	}
+	if d <= 0 {
+		ctx.finish(context.DeadlineExceeded)
+		return ctx, func() {}
+	}
+
+	stopParent := context.AfterFunc(parent, func() { ctx.finish(parent.Err()) })
+	timer := c.AfterFunc(d, func() { ctx.finish(context.DeadlineExceeded) })
+	return ctx, func() {
+		stopParent()
+		timer.Stop()
+		ctx.finish(context.Canceled)
+	}
+}
+
+func (c *timeoutCtx) Deadline() (time.Time, bool) {
+	return c.deadline, true
+}
+
+func (c *timeoutCtx) Done() <-chan struct{} {
+	return c.done
+}
+
+func (c *timeoutCtx) Err() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.err
+}
+
+// finish ends the context with err, the first reason wins
+func (c *timeoutCtx) finish(err error) {
+	c.once.Do(func() {
+		c.mu.Lock()
+		c.err = err
+		c.mu.Unlock()
+		close(c.done)
+	})
+}
@@ -0,0 +1,232 @@
+package clock
+
+import (
+	"sync"
+	"time"
+)
+
+// Fake is a clock that only moves when told to. Timers, tickers, sleeps
+// and AfterFuncs fire in order of their due time as Advance walks past
+// them, AfterFunc callbacks right there on the goroutine calling Advance.
+type Fake struct {
+	mu      sync.Mutex
+	changed *sync.Cond
+	now     time.Time
+	waiters []*waiter
+	seq     uint64
+}
+
+// waiter is anything waiting for the fake clock to reach a point in time
+type waiter struct {
+	when time.Time
+	// breaks ties, so things due at the same time fire in creation order
+	seq uint64
+	// non-zero for tickers
+	period time.Duration
+	ch     chan time.Time
+	fn     func()
+}
+
+// fakeTimer is a Timer on a Fake clock
+type fakeTimer struct {
+	clock *Fake
+	w     *waiter
+}
+
+// fakeTicker is a Ticker on a Fake clock
+type fakeTicker struct {
+	clock *Fake
+	w     *waiter
+}
+
+// NewFake creates a fake clock showing now
+func NewFake(now time.Time) *Fake {
+	f := &Fake{now: now}
+	f.changed = sync.NewCond(&f.mu)
+	return f
+}
+
+// Advance moves the clock forward by d, firing everything that comes due
+// on the way
+func (f *Fake) Advance(d time.Duration) {
+	f.mu.Lock()
+	target := f.now.Add(d)
+	f.mu.Unlock()
+	f.advanceTo(target)
+}
+
+// Set moves the clock to t, firing everything due by then. The clock
+// doesn't go backwards, a t in the past is ignored.
+func (f *Fake) Set(t time.Time) {
+	f.advanceTo(t)
+}
+
+// BlockUntil waits until at least n timers, tickers, sleeps or AfterFuncs
+// are pending, so a test knows the goroutine it's racing is parked on the
+// clock before calling Advance
+func (f *Fake) BlockUntil(n int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for len(f.waiters) < n {
+		f.changed.Wait()
+	}
+}
+
+// Pending returns how many timers, tickers, sleeps and AfterFuncs are waiting
+func (f *Fake) Pending() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return len(f.waiters)
+}
+
+func (f *Fake) Now() time.Time {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.now
+}
+
+func (f *Fake) Since(t time.Time) time.Duration {
+	return f.Now().Sub(t)
+}
+
+func (f *Fake) Until(t time.Time) time.Duration {
+	return t.Sub(f.Now())
+}
+
+// Sleep blocks until someone advances the clock by d
+func (f *Fake) Sleep(d time.Duration) {
+	if d <= 0 {
+		return
+	}
+	<-f.After(d)
+}
+
+func (f *Fake) After(d time.Duration) <-chan time.Time {
+	return f.NewTimer(d).C()
+}
+
+func (f *Fake) NewTimer(d time.Duration) Timer {
+	w := &waiter{ch: make(chan time.Time, 1)}
+	f.schedule(w, d)
+	return &fakeTimer{clock: f, w: w}
+}
+
+// NewTicker panics on a non-positive d, like time.NewTicker does
+func (f *Fake) NewTicker(d time.Duration) Ticker {
+	if d <= 0 {
+		panic("clock: non-positive interval for NewTicker")
+	}
+	w := &waiter{ch: make(chan time.Time, 1), period: d}
+	f.schedule(w, d)
+	return &fakeTicker{clock: f, w: w}
+}
+
+func (f *Fake) AfterFunc(d time.Duration, fn func()) Timer {
+	w := &waiter{fn: fn}
+	f.schedule(w, d)
+	return &fakeTimer{clock: f, w: w}
+}
+
+func (t *fakeTimer) C() <-chan time.Time {
+	return t.w.ch
+}
+
+func (t *fakeTimer) Stop() bool {
+	return t.clock.remove(t.w)
+}
+
+func (t *fakeTimer) Reset(d time.Duration) bool {
+	active := t.clock.remove(t.w)
+	t.clock.schedule(t.w, d)
+	return active
+}
+
+func (t *fakeTicker) C() <-chan time.Time {
+	return t.w.ch
+}
+
+func (t *fakeTicker) Stop() {
+	t.clock.remove(t.w)
+}
+
+func (t *fakeTicker) Reset(d time.Duration) {
+	if d <= 0 {
+		panic("clock: non-positive interval for Ticker.Reset")
+	}
+	t.clock.remove(t.w)
+	t.w.period = d
+	t.clock.schedule(t.w, d)
+}
+
+// schedule makes w due d from now
+func (f *Fake) schedule(w *waiter, d time.Duration) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.seq++
+	w.when, w.seq = f.now.Add(d), f.seq
+	f.waiters = append(f.waiters, w)
+	f.changed.Broadcast()
+}
+
+// remove unschedules w, reporting whether it was still pending
+func (f *Fake) remove(w *waiter) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for i, pending := range f.waiters {
+		if pending == w {
+			f.waiters = append(f.waiters[:i], f.waiters[i+1:]...)
+			f.changed.Broadcast()
+			return true
+		}
+	}
+	return false
+}
+
+// advanceTo fires everything due by target, one at a time and in order,
+// with the clock showing each one's due time as it fires
+func (f *Fake) advanceTo(target time.Time) {
+	for {
+		f.mu.Lock()
+		next := -1
+		for i, w := range f.waiters {
+			if w.when.After(target) {
+				continue
+			}
+			if next < 0 || w.when.Before(f.waiters[next].when) ||
+				(w.when.Equal(f.waiters[next].when) && w.seq < f.waiters[next].seq) {
+				next = i
+			}
+		}
+		if next < 0 {
+			if target.After(f.now) {
+				f.now = target
+			}
+			f.mu.Unlock()
+			return
+		}
+
+		w := f.waiters[next]
+		if w.when.After(f.now) {
+			f.now = w.when
+		}
+		now := f.now
+		if w.period > 0 {
+			f.seq++
+			w.when, w.seq = w.when.Add(w.period), f.seq
+		} else {
+			f.waiters = append(f.waiters[:next], f.waiters[next+1:]...)
+			f.changed.Broadcast()
+		}
+		f.mu.Unlock()
+
+		if w.fn != nil {
+			w.fn()
+			continue
+		}
+		// Like the real thing, a tick nobody picked up is dropped
+		select {
+		case w.ch <- now:
+		default:
+		}
+	}
+}
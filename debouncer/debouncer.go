@@ -4,22 +4,41 @@ package debouncer
 import (
 	"sync"
 	"time"
+
+	"github.com/theHamdiz/it/clock"
 )
 
 // Debouncer is like a bouncer for your function calls
 // Keeps the eager ones waiting outside until the VIPs have left
 type Debouncer struct {
 	mu    sync.Mutex    // The velvet rope
-	timer *time.Timer   // The "maybe later" timer
+	timer clock.Timer   // The "maybe later" timer
 	delay time.Duration // How long we make them wait
+	clock clock.Clock   // Whose watch we're going by
+}
+
+// DebouncerOption adjusts a debouncer before it starts shushing
+type DebouncerOption func(*Debouncer)
+
+// WithClock runs the cooldown timer on c, so a test can fast-forward past
+// the quiet period instead of sleeping through it
+func WithClock(c clock.Clock) DebouncerOption {
+	return func(d *Debouncer) {
+		d.clock = clock.Or(c)
+	}
 }
 
 // NewDebouncer creates a new function cooldown manager
 // delay: how long until we're ready to party again
-func NewDebouncer(delay time.Duration) *Debouncer {
-	return &Debouncer{
+func NewDebouncer(delay time.Duration, opts ...DebouncerOption) *Debouncer {
+	d := &Debouncer{
 		delay: delay, // The mandatory cool-off period
+		clock: clock.Real(),
+	}
+	for _, opt := range opts {
+		opt(d)
 	}
+	return d
 }
 
 // Debounce wraps your hyperactive function in a calm, collected exterior
@@ -34,7 +53,7 @@ func (d *Debouncer) Debounce(fn func()) func() {
 			d.timer.Stop()
 		}
 		// Come back later
-		d.timer = time.AfterFunc(d.delay, fn)
+		d.timer = d.clock.AfterFunc(d.delay, fn)
 	}
 }
 
@@ -94,13 +113,13 @@ func (d *Debouncer) IsStopped() bool {
 	return !d.IsRunning()
 }
 
-// Timer returns the actual timer
+// Timer returns the actual timer, nil when there's none or it isn't a real one
 // But seriously, you probably shouldn't mess with this
 func (d *Debouncer) Timer() *time.Timer {
 	d.mu.Lock()
 	defer d.mu.Unlock()
 
-	return d.timer
+	return clock.Underlying(d.timer)
 }
 
 // Stop is like Cancel but sounds more professional
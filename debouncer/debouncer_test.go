@@ -5,6 +5,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/theHamdiz/it/clock"
 	"github.com/theHamdiz/it/debouncer"
 )
 
@@ -83,3 +84,27 @@ func TestDebouncer_Debounce_MultipleExecutions(t *testing.T) {
 		t.Errorf("Expected function to execute twice, but executed %d times", executed)
 	}
 }
+
+// TestDebouncer_FakeClock checks debouncing without sleeping through it
+func TestDebouncer_FakeClock(t *testing.T) {
+	fake := clock.NewFake(time.Now())
+	d := debouncer.NewDebouncer(time.Second, debouncer.WithClock(fake))
+
+	var calls int32
+	fn := d.Debounce(func() { atomic.AddInt32(&calls, 1) })
+	fn()
+	fake.Advance(900 * time.Millisecond)
+	fn() // Starts the second over
+	fake.Advance(900 * time.Millisecond)
+	if atomic.LoadInt32(&calls) != 0 {
+		t.Fatal("Expected the call to keep being pushed back")
+	}
+
+	fake.Advance(100 * time.Millisecond)
+	if atomic.LoadInt32(&calls) != 1 {
+		t.Errorf("Expected exactly one call, got %d", calls)
+	}
+	if d.Timer() != nil {
+		t.Error("Expected no *time.Timer behind a fake clock")
+	}
+}
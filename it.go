@@ -38,8 +38,8 @@ import (
 	"log"
 	"os"
 	"reflect"
-	"runtime"
 	"runtime/debug"
+	"strconv"
 	"strings"
 	"sync"
 	"syscall"
@@ -47,6 +47,7 @@ import (
 
 	"github.com/theHamdiz/it/cfg"
 	"github.com/theHamdiz/it/logger"
+	"github.com/theHamdiz/it/logger/audit"
 	"github.com/theHamdiz/it/retry"
 	"github.com/theHamdiz/it/rl"
 	"github.com/theHamdiz/it/sm"
@@ -84,6 +85,11 @@ func Must[T any](operation func() (T, error)) T {
 			return operation()
 		})
 	if err != nil {
+		// Say what every attempt ran into, not just the last one
+		var retryErr *retry.RetryError
+		if errors.As(err, &retryErr) {
+			panic("all retries failed: " + retryErr.Detail())
+		}
 		panic(fmt.Sprintf("all retries failed: %v", err))
 	}
 	return result
@@ -156,6 +162,10 @@ func WrapError(err error, message string, metadata map[string]any) error {
 
 	wrappedMessage := fmt.Sprintf("%s: %v", message, err)
 	if metadata != nil && len(metadata) > 0 {
+		// Error messages end up in logs sooner or later, so scrub them the same way
+		if redactor := logger.DefaultLogger().Redactor(); redactor != nil {
+			metadata = redactor.Redact(metadata)
+		}
 		wrappedMessage += fmt.Sprintf(" | Metadata: %+v", metadata)
 	}
 
@@ -229,29 +239,48 @@ func Trace(msg string) {
 
 // LogStackTrace Stack Trace Logging
 func LogStackTrace() {
-	buf := make([]byte, 1024)
-	n := runtime.Stack(buf, false)
-	logger.DefaultLogger().Info(string(buf[:n]))
+	logger.DefaultLogger().Info(string(debug.Stack()))
 }
 
 func LogErrorWithStack(err error) {
 	if err == nil {
 		return
 	}
-	buf := make([]byte, 1024)
-	n := runtime.Stack(buf, false)
-	logger.DefaultLogger().Errorf("Error: %v\nStack Trace:\n%s", err, string(buf[:n]))
+	// debug.Stack grows its buffer until the whole trace fits
+	logger.DefaultLogger().Errorf("Error: %v\nStack Trace:\n%s", err, debug.Stack())
+}
+
+// ErrorErr logs err with its cause chain and stack as a structured entry
+func ErrorErr(err error, msg string, fields map[string]any) {
+	logger.DefaultLogger().ErrorErr(err, msg, fields)
 }
 
 func LogOnce(msg string) {
 	logger.DefaultLogger().LogOnce(msg)
 }
 
-// Audit logging
+// Audit logging. Once an audit trail is configured (cfg.WithAuditLog or
+// AUDIT_FILE) the message goes there instead of next to the normal logs.
 func Audit(msg string) {
+	if audit.Default() != nil {
+		if err := AuditEvent(audit.Event{Action: msg, Outcome: audit.OutcomeSuccess}); err == nil {
+			return
+		}
+	}
 	logger.DefaultLogger().StructuredLog(logger.LevelAudit, msg, nil)
 }
 
+// AuditEvent records who did what to which resource, and how it went, in
+// the tamper-evident audit trail
+func AuditEvent(e audit.Event) error {
+	if _, err := audit.Record(e); err != nil {
+		// Losing an audit entry quietly is the one thing we can't do
+		logger.DefaultLogger().Errorf("Failed to write audit entry %q: %v", e.Action, err)
+		return err
+	}
+	return nil
+}
+
 // StructuredInfo Structured Logging of information.
 func StructuredInfo(message string, data map[string]any) {
 	logger.DefaultLogger().StructuredInfo(message, data)
@@ -299,66 +328,26 @@ func SetLogOutput(writer *os.File) {
 
 // Retry retries a function with a fixed delay
 func Retry(attempts int, delay time.Duration, operation func() error) error {
-	// Create a context that will be used for retries
-	ctx := context.Background()
-
-	// Convert the simple operation to one that accepts context
-	contextOperation := func(ctx context.Context) (interface{}, error) {
-		return nil, operation()
-	}
-
-	// Create config for fixed delay retries
-	config := retry.Config{
-		Attempts:     attempts,
-		InitialDelay: delay,
-		MaxDelay:     delay, // Keep delay fixed
-		Multiplier:   1.0,   // No multiplication
-		RandomFactor: 0.0,   // No jitter
-	}
-
-	_, err := retry.WithBackoff(ctx, config, contextOperation)
-	return err
+	return RetryWithBackoff(context.Background(), attempts, retry.Constant(delay), operation)
 }
 
 // RetryExponential retries a function with exponential backoff
 func RetryExponential(attempts int, initialDelay time.Duration, operation func() error) error {
-	ctx := context.Background()
-
-	contextOperation := func(ctx context.Context) (interface{}, error) {
-		return nil, operation()
-	}
-
-	config := retry.Config{
-		Attempts:     attempts,
-		InitialDelay: initialDelay,
-		MaxDelay:     initialDelay * time.Duration(1<<uint(attempts)), // Max delay based on attempts
+	return RetryWithBackoff(context.Background(), attempts, retry.ExponentialBackoff{
+		Initial:      initialDelay,
+		Max:          initialDelay * time.Duration(1<<uint(attempts)), // Max delay based on attempts
 		Multiplier:   2.0,
 		RandomFactor: 0.1,
-	}
-
-	_, err := retry.WithBackoff(ctx, config, contextOperation)
-	return err
+	}, operation)
 }
 
 // RetryWithContext retries a function with a fixed delay, respecting context cancellation
 func RetryWithContext(ctx context.Context, attempts int, delay time.Duration, operation func() error) error {
-	contextOperation := func(ctx context.Context) (interface{}, error) {
-		return nil, operation()
-	}
-
-	config := retry.Config{
-		Attempts:     attempts,
-		InitialDelay: delay,
-		MaxDelay:     delay, // Keep delay fixed
-		Multiplier:   1.0,   // No multiplication
-		RandomFactor: 0.0,   // No jitter
-	}
-
-	_, err := retry.WithBackoff(ctx, config, contextOperation)
-	return err
+	return RetryWithBackoff(ctx, attempts, retry.Constant(delay), operation)
 }
 
-// RetryExponentialWithContext retries a function with exponential backoff, respecting context cancellation
+// RetryExponentialWithContext retries a function with exponential backoff, respecting context cancellation.
+// The operation is abandoned, not waited for, once ctx is done.
 func RetryExponentialWithContext(ctx context.Context, attempts int, initialDelay time.Duration, operation func() error) error {
 	if attempts <= 0 {
 		return errors.New("attempts must be greater than 0")
@@ -367,45 +356,29 @@ func RetryExponentialWithContext(ctx context.Context, attempts int, initialDelay
 		return errors.New("initial delay must be greater than 0")
 	}
 
-	var lastErr error
-	for i := 0; i < attempts; i++ {
-		// Create a done channel for the operation
+	return RetryWithBackoff(ctx, attempts, retry.Exponential(initialDelay, 0), func() error {
 		done := make(chan error, 1)
-
-		// Run the operation in a goroutine
 		go func() {
 			done <- operation()
 		}()
 
-		// Wait for either the operation to complete or context to be cancelled
 		select {
 		case err := <-done:
-			if err == nil {
-				return nil // Success
-			}
-			lastErr = err
-		case <-ctx.Done():
-			return ctx.Err()
-		}
-
-		// Don't sleep after the last attempt
-		if i == attempts-1 {
-			break
-		}
-
-		// Calculate delay: initialDelay * (2^i)
-		delay := initialDelay * time.Duration(1<<uint(i))
-
-		// Wait for either the delay or context cancellation
-		select {
+			return err
 		case <-ctx.Done():
 			return ctx.Err()
-		case <-time.After(delay):
-			continue
 		}
-	}
+	})
+}
 
-	return lastErr
+// RetryWithBackoff retries a function, waiting between attempts however backoff
+// says: retry.Constant, retry.Fibonacci, retry.DecorrelatedJitter, or your own
+func RetryWithBackoff(ctx context.Context, attempts int, backoff retry.Backoff, operation func() error) error {
+	_, err := retry.WithBackoff(ctx, retry.Config{Attempts: attempts, Backoff: backoff},
+		func(ctx context.Context) (any, error) {
+			return nil, operation()
+		})
+	return err
 }
 
 // ===================================================
@@ -453,6 +426,9 @@ func GracefulShutdown(
 		)
 	}
 
+	// Last one out flushes the logs, in case they're buffered.
+	manager.AddAction("logger-flush", logger.DefaultLogger().Flush, timeout, false)
+
 	// Start the shutdown manager.
 	manager.Start()
 
@@ -514,6 +490,9 @@ func GracefulRestart(
 		)
 	}
 
+	// Last one out flushes the logs, in case they're buffered.
+	manager.AddAction("logger-flush", logger.DefaultLogger().Flush, timeout, false)
+
 	// Start the shutdown manager.
 	manager.Start()
 
@@ -688,11 +667,20 @@ func InitFromEnv() {
 		}
 	}
 
-	// Handle LOG_FILE
+	// Handle LOG_FORMAT before LOG_FILE, so the file gets it too
+	if format, err := logger.ParseLogFormat(os.Getenv("LOG_FORMAT")); err == nil {
+		logger.SetLogFormat(format)
+	}
+
+	// Handle LOG_FILE, rolled according to the LOG_MAX_* family
 	if logFile := os.Getenv("LOG_FILE"); logFile != "" {
-		file, err := os.OpenFile(logFile, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
-		if err == nil {
-			SetLogOutput(file)
+		_, _ = logger.SetLogFile(logFile, parseRotationFromEnv())
+	}
+
+	// Handle AUDIT_FILE, signed with AUDIT_KEY
+	if auditFile := os.Getenv("AUDIT_FILE"); auditFile != "" {
+		if err := audit.SetFile(auditFile, []byte(os.Getenv("AUDIT_KEY"))); err != nil {
+			logger.DefaultLogger().Errorf("Audit trail unavailable: %v", err)
 		}
 	}
 }
@@ -740,6 +728,38 @@ func parseLogLevel(level string) logger.LogLevel {
 	}
 }
 
+// parseRotationFromEnv reads the rotation policy for InitFromEnv:
+//   - LOG_MAX_SIZE_MB: roll once the file reaches this many megabytes
+//   - LOG_ROTATE_EVERY: roll on a schedule, as a Go duration ("24h")
+//   - LOG_MAX_BACKUPS: how many rolled files to keep
+//   - LOG_MAX_AGE: delete rolled files older than this Go duration
+//   - LOG_COMPRESS: gzip rolled files
+//   - LOG_REOPEN_ON_SIGHUP: reopen the file when logrotate says so
+//
+// Anything unparsable is ignored, same as LOG_LEVEL.
+func parseRotationFromEnv() logger.RotationConfig {
+	var rotation logger.RotationConfig
+	if mb, err := strconv.ParseInt(os.Getenv("LOG_MAX_SIZE_MB"), 10, 64); err == nil && mb > 0 {
+		rotation.MaxSize = mb * 1024 * 1024
+	}
+	if every, err := time.ParseDuration(os.Getenv("LOG_ROTATE_EVERY")); err == nil && every > 0 {
+		rotation.RotateEvery = every
+	}
+	if backups, err := strconv.Atoi(os.Getenv("LOG_MAX_BACKUPS")); err == nil && backups > 0 {
+		rotation.MaxBackups = backups
+	}
+	if age, err := time.ParseDuration(os.Getenv("LOG_MAX_AGE")); err == nil && age > 0 {
+		rotation.MaxAge = age
+	}
+	if compress, err := strconv.ParseBool(os.Getenv("LOG_COMPRESS")); err == nil {
+		rotation.Compress = compress
+	}
+	if reopen, err := strconv.ParseBool(os.Getenv("LOG_REOPEN_ON_SIGHUP")); err == nil {
+		rotation.ReopenOnSIGHUP = reopen
+	}
+	return rotation
+}
+
 // callShutdown is a helper that calls the Shutdown method on a server instance.
 // It supports both signatures:
 //   - Shutdown(context.Context) error
@@ -3,10 +3,12 @@ package it_test
 import (
 	"context"
 	"errors"
+	"fmt"
 	"io"
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"path/filepath"
 	"strings"
 	"sync"
 	"sync/atomic"
@@ -15,6 +17,10 @@ import (
 	"time"
 
 	"github.com/theHamdiz/it"
+	"github.com/theHamdiz/it/logger"
+	"github.com/theHamdiz/it/logger/audit"
+	"github.com/theHamdiz/it/logger/logtest"
+	"github.com/theHamdiz/it/retry"
 	"golang.org/x/crypto/bcrypt"
 )
 
@@ -49,9 +55,14 @@ func TestMust(t *testing.T) {
 
 	// Test panic case
 	defer func() {
-		if r := recover(); r == nil {
+		r := recover()
+		if r == nil {
 			t.Error("Expected Must to panic on error")
 		}
+		// Every attempt should be accounted for
+		if msg := fmt.Sprint(r); !strings.Contains(msg, "attempt 3 at") {
+			t.Errorf("Expected the panic to list each attempt, got %q", msg)
+		}
 	}()
 
 	it.Must(func() (string, error) {
@@ -194,6 +205,23 @@ func TestLogging(t *testing.T) {
 	}
 }
 
+func TestLogErrorWithStackIsNotTruncated(t *testing.T) {
+	rec := logtest.Capture(t)
+
+	// Far more than the old 1024-byte buffer could hold
+	var recurse func(n int)
+	recurse = func(n int) {
+		if n == 0 {
+			it.LogErrorWithStack(errors.New("deep trouble"))
+			return
+		}
+		recurse(n - 1)
+	}
+	recurse(50)
+
+	rec.AssertContains(t, logger.LevelError, "TestLogErrorWithStackIsNotTruncated(", nil)
+}
+
 // TestRetry tests retry functionality
 func TestRetry(t *testing.T) {
 	attempts := 0
@@ -213,6 +241,22 @@ func TestRetry(t *testing.T) {
 	}
 }
 
+// TestRetryWithBackoff tests retrying with a pluggable backoff
+func TestRetryWithBackoff(t *testing.T) {
+	attempts := 0
+	err := it.RetryWithBackoff(context.Background(), 4, retry.Fibonacci(time.Millisecond, 0), func() error {
+		attempts++
+		return errors.New("still no")
+	})
+
+	if !errors.Is(err, retry.ErrAttemptsExhausted) || !strings.Contains(err.Error(), "still no") {
+		t.Errorf("Expected attempts to run out on the last error, got %v", err)
+	}
+	if attempts != 4 {
+		t.Errorf("Expected 4 attempts, got %d", attempts)
+	}
+}
+
 type mockServer struct {
 	shutdownCalled bool
 	shutdownError  error
@@ -803,6 +847,28 @@ func TestAuditLogging(t *testing.T) {
 	}
 }
 
+func TestAuditTrail(t *testing.T) {
+	rec := logtest.Capture(t)
+	path := filepath.Join(t.TempDir(), "audit.log")
+	key := []byte("s3cret")
+	if err := audit.SetFile(path, key); err != nil {
+		t.Fatal(err)
+	}
+	defer audit.SetDefault(nil)
+
+	it.Audit("sensitive operation performed")
+	if err := it.AuditEvent(audit.Event{Actor: "alice", Action: "user.delete", Resource: "user/7", Outcome: audit.OutcomeDenied}); err != nil {
+		t.Fatal(err)
+	}
+
+	last, err := audit.VerifyFile(path, key)
+	if err != nil || last.Seq != 2 || last.Actor != "alice" {
+		t.Errorf("Expected both entries in a valid trail, got %+v, %v", last, err)
+	}
+	// Separate from the operational logs
+	rec.AssertEmpty(t)
+}
+
 // TestTimeBlock measures block execution time
 func TestTimeBlock(t *testing.T) {
 	done := it.TimeBlock("test_block")
@@ -865,3 +931,21 @@ func BenchmarkStructuredLogging(b *testing.B) {
 		it.StructuredInfo("benchmark message", data)
 	}
 }
+
+// TestWrapErrorRedactsMetadata ensures secrets don't ride along in error messages
+func TestWrapErrorRedactsMetadata(t *testing.T) {
+	logger.SetRedactor(logger.DefaultRedactor())
+	defer logger.SetRedactor(nil)
+
+	err := it.WrapError(errors.New("boom"), "login failed", map[string]any{
+		"user":     "bob",
+		"password": "hunter2",
+	})
+
+	if strings.Contains(err.Error(), "hunter2") {
+		t.Errorf("Expected password to be redacted, got %q", err.Error())
+	}
+	if !strings.Contains(err.Error(), "user:bob") {
+		t.Errorf("Expected harmless metadata to survive, got %q", err.Error())
+	}
+}
@@ -4,7 +4,8 @@ package lb
 import (
 	"context"
 	"errors"
-	"time"
+
+	"github.com/theHamdiz/it/clock"
 )
 
 // LoadBalancer is like a bouncer for your goroutines
@@ -13,27 +14,40 @@ type LoadBalancer struct {
 	workers chan struct{}      // The VIP list
 	ctx     context.Context    // The party's context
 	cancel  context.CancelFunc // The "everybody out" button
+	clock   clock.Clock        // For keeping an eye on deadlines
+}
+
+// LoadBalancerOption adjusts a load balancer before it opens the doors
+type LoadBalancerOption func(*LoadBalancer)
+
+// WithClock has Execute time its wait for a worker on c. The deadline of
+// the ctx it's given is read off c as well, so a clock.Fake wants a ctx
+// from clock.WithTimeout on the same fake, not a wall-clock deadline.
+func WithClock(c clock.Clock) LoadBalancerOption {
+	return func(lb *LoadBalancer) {
+		lb.clock = clock.Or(c)
+	}
 }
 
 // NewLoadBalancer creates a new work distribution committee
 // maxWorkers: how many goroutines we trust at once
-func NewLoadBalancer(maxWorkers int) *LoadBalancer {
-	ctx, cancel := context.WithCancel(context.Background())
-	return &LoadBalancer{
-		workers: make(chan struct{}, maxWorkers), // Our exclusive guest list
-		ctx:     ctx,
-		cancel:  cancel,
-	}
+func NewLoadBalancer(maxWorkers int, opts ...LoadBalancerOption) *LoadBalancer {
+	return NewLoadBalancerWithContext(context.Background(), maxWorkers, opts...)
 }
 
 // NewLoadBalancerWithContext is like NewLoadBalancer but with a bedtime
-func NewLoadBalancerWithContext(ctx context.Context, maxWorkers int) *LoadBalancer {
+func NewLoadBalancerWithContext(ctx context.Context, maxWorkers int, opts ...LoadBalancerOption) *LoadBalancer {
 	ctx, cancel := context.WithCancel(ctx)
-	return &LoadBalancer{
-		workers: make(chan struct{}, maxWorkers),
+	lb := &LoadBalancer{
+		workers: make(chan struct{}, maxWorkers), // Our exclusive guest list
 		ctx:     ctx,
 		cancel:  cancel,
+		clock:   clock.Real(),
+	}
+	for _, opt := range opts {
+		opt(lb)
 	}
+	return lb
 }
 
 // Execute runs your function through security
@@ -52,7 +66,7 @@ func (lb *LoadBalancer) Execute(ctx context.Context, operation func() error) err
 
 	deadline, hasDeadline := ctx.Deadline()
 	if hasDeadline {
-		timer := time.NewTimer(time.Until(deadline))
+		timer := lb.clock.NewTimer(lb.clock.Until(deadline))
 		defer timer.Stop()
 
 		select {
@@ -63,7 +77,7 @@ func (lb *LoadBalancer) Execute(ctx context.Context, operation func() error) err
 		case <-lb.ctx.Done():
 			return errors.New("load balancer is closed")
 		// Time's up, go home
-		case <-timer.C:
+		case <-timer.C():
 			return context.DeadlineExceeded
 		// Don't forget to return your VIP pass
 		case lb.workers <- struct{}{}:
@@ -6,6 +6,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/theHamdiz/it/clock"
 	"github.com/theHamdiz/it/lb"
 )
 
@@ -91,6 +92,39 @@ func TestLoadBalancer_Execute_LimitExceeded(t *testing.T) {
 	}
 }
 
+func TestLoadBalancer_Execute_DeadlineOnFakeClock(t *testing.T) {
+	fake := clock.NewFake(time.Now())
+	lb_ := lb.NewLoadBalancer(1, lb.WithClock(fake))
+	defer lb_.Close()
+	lb_.Workers() <- struct{}{} // Every seat taken
+
+	ctx, cancel := clock.WithTimeout(context.Background(), fake, 5*time.Second)
+	defer cancel()
+	done := make(chan error, 1)
+	go func() {
+		done <- lb_.Execute(ctx, func() error { return nil })
+	}()
+
+	// The ctx deadline and Execute's own timer
+	fake.BlockUntil(2)
+	fake.Advance(4 * time.Second)
+	select {
+	case err := <-done:
+		t.Fatalf("Expected Execute to wait out the deadline, got %v", err)
+	default:
+	}
+
+	fake.Advance(time.Second)
+	select {
+	case err := <-done:
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("Expected context.DeadlineExceeded, got %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Expected Execute to give up once the fake deadline passed")
+	}
+}
+
 func TestLoadBalancer_Execute_LoadBalancerClosed(t *testing.T) {
 	lb_ := lb.NewLoadBalancer(1)
 	lb_.Close()
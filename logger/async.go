@@ -0,0 +1,308 @@
+package logger
+
+// ===================================================
+// Imports Area
+// ===================================================
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ===================================================
+// Definitions Area
+// ===================================================
+
+// OverflowPolicy decides what happens when the async buffer is full
+type OverflowPolicy int
+
+// AsyncConfig tunes the async mode
+type AsyncConfig struct {
+	BufferSize int            // How many records we hold before the policy kicks in
+	Overflow   OverflowPolicy // What to sacrifice when the buffer is full
+}
+
+// Flusher is implemented by handlers that sit on records for a while
+type Flusher interface {
+	Flush(ctx context.Context) error
+}
+
+// AsyncHandler queues records in a bounded ring buffer and hands them to
+// the wrapped handlers from a background goroutine, so a slow disk stalls
+// the flusher instead of your hot path
+type AsyncHandler struct {
+	handlers []Handler
+	policy   OverflowPolicy
+
+	mu       sync.Mutex
+	notEmpty *sync.Cond
+	notFull  *sync.Cond
+	ring     []Record
+	head     int
+	count    int
+	// records handed to the flusher but not written yet
+	inflight int
+	// drops not yet reported downstream
+	pendingDrops uint64
+	closed       bool
+	// closed and replaced every time the flusher runs dry
+	idle chan struct{}
+	// closed when the flusher goroutine exits
+	done chan struct{}
+
+	dropped atomic.Uint64
+}
+
+// ===================================================
+// Declarations Area
+// ===================================================
+
+const (
+	// OverflowBlock makes callers wait for room, nothing is lost
+	OverflowBlock OverflowPolicy = iota
+	// OverflowDropNewest throws away the record that didn't fit
+	OverflowDropNewest
+	// OverflowDropOldest makes room by evicting the oldest queued record
+	OverflowDropOldest
+)
+
+const defaultAsyncBufferSize = 1024
+
+// ===================================================
+// Public Functions Area
+// ===================================================
+
+func (p OverflowPolicy) String() string {
+	switch p {
+	case OverflowBlock:
+		return "block"
+	case OverflowDropNewest:
+		return "drop-newest"
+	case OverflowDropOldest:
+		return "drop-oldest"
+	default:
+		return "unknown"
+	}
+}
+
+// NewAsyncHandler starts a background flusher feeding handlers
+func NewAsyncHandler(config AsyncConfig, handlers ...Handler) *AsyncHandler {
+	if config.BufferSize <= 0 {
+		config.BufferSize = defaultAsyncBufferSize
+	}
+	h := &AsyncHandler{
+		handlers: append([]Handler(nil), handlers...),
+		policy:   config.Overflow,
+		ring:     make([]Record, config.BufferSize),
+		idle:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+	h.notEmpty = sync.NewCond(&h.mu)
+	h.notFull = sync.NewCond(&h.mu)
+
+	go h.run()
+	return h
+}
+
+// EnableAsync moves every handler of this logger behind one AsyncHandler
+// and returns it, so you can Flush or Close it on the way out
+func (l *Logger) EnableAsync(config AsyncConfig) *AsyncHandler {
+	var async *AsyncHandler
+	l.updatePipeline(func(p *pipeline) {
+		async = NewAsyncHandler(config, p.handlers...)
+		p.handlers = []Handler{async}
+	})
+	return async
+}
+
+// Flush waits until every handler holding records has written them out
+func (l *Logger) Flush(ctx context.Context) error {
+	for _, h := range l.pipeline().handlers {
+		if f, ok := h.(Flusher); ok {
+			if err := f.Flush(ctx); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (h *AsyncHandler) Enabled(level LogLevel) bool {
+	for _, inner := range h.handlers {
+		if inner.Enabled(level) {
+			return true
+		}
+	}
+	return false
+}
+
+// Handle queues r according to the overflow policy. Once the handler is
+// closed, records are written synchronously so late stragglers still land.
+func (h *AsyncHandler) Handle(r Record) error {
+	h.mu.Lock()
+
+	for h.count == len(h.ring) && !h.closed {
+		switch h.policy {
+		case OverflowDropNewest:
+			h.drop()
+			h.mu.Unlock()
+			return nil
+		case OverflowDropOldest:
+			h.head = (h.head + 1) % len(h.ring)
+			h.count--
+			h.drop()
+		default:
+			h.notFull.Wait()
+		}
+	}
+
+	if h.closed {
+		h.mu.Unlock()
+		return h.write(r)
+	}
+
+	h.ring[(h.head+h.count)%len(h.ring)] = r
+	h.count++
+	h.notEmpty.Signal()
+	h.mu.Unlock()
+	return nil
+}
+
+// Flush blocks until the buffer has run dry, or ctx gives up
+func (h *AsyncHandler) Flush(ctx context.Context) error {
+	h.mu.Lock()
+	if h.isIdle() {
+		h.mu.Unlock()
+		return nil
+	}
+	idle := h.idle
+	h.mu.Unlock()
+
+	select {
+	case <-idle:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Close drains the buffer and stops the flusher. Its signature fits
+// sm.ShutdownManager.AddAction as-is.
+func (h *AsyncHandler) Close(ctx context.Context) error {
+	h.mu.Lock()
+	h.closed = true
+	h.notEmpty.Broadcast()
+	h.notFull.Broadcast()
+	h.mu.Unlock()
+
+	select {
+	case <-h.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Dropped reports how many records the overflow policy has thrown away
+func (h *AsyncHandler) Dropped() uint64 {
+	return h.dropped.Load()
+}
+
+// Len reports how many records are waiting in the buffer
+func (h *AsyncHandler) Len() int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.count
+}
+
+// ===================================================
+// Private Functions Area
+// ===================================================
+
+// drop must be called with h.mu held
+func (h *AsyncHandler) drop() {
+	h.pendingDrops++
+	h.dropped.Add(1)
+	// Wake the flusher so the drop gets reported even if nothing else arrives
+	h.notEmpty.Signal()
+}
+
+// isIdle must be called with h.mu held
+func (h *AsyncHandler) isIdle() bool {
+	return h.count == 0 && h.inflight == 0 && h.pendingDrops == 0
+}
+
+func (h *AsyncHandler) run() {
+	defer close(h.done)
+
+	batch := make([]Record, 0, len(h.ring))
+	for {
+		h.mu.Lock()
+		for h.count == 0 && h.pendingDrops == 0 && !h.closed {
+			h.notEmpty.Wait()
+		}
+		if h.isIdle() && h.closed {
+			close(h.idle)
+			h.mu.Unlock()
+			return
+		}
+
+		batch = batch[:0]
+		for h.count > 0 {
+			batch = append(batch, h.ring[h.head])
+			h.ring[h.head] = Record{}
+			h.head = (h.head + 1) % len(h.ring)
+			h.count--
+		}
+		drops := h.pendingDrops
+		h.pendingDrops = 0
+		h.inflight = len(batch)
+		h.notFull.Broadcast()
+		h.mu.Unlock()
+
+		if drops > 0 {
+			h.report(h.write(Record{
+				Time:       time.Now(),
+				Level:      LevelWarning,
+				Message:    fmt.Sprintf("async logger dropped %d records", drops),
+				Data:       map[string]any{"dropped": drops},
+				Structured: true,
+			}))
+		}
+		for _, r := range batch {
+			h.report(h.write(r))
+		}
+
+		h.mu.Lock()
+		h.inflight = 0
+		if h.isIdle() {
+			close(h.idle)
+			h.idle = make(chan struct{})
+		}
+		h.mu.Unlock()
+	}
+}
+
+func (h *AsyncHandler) write(r Record) error {
+	var firstErr error
+	for _, inner := range h.handlers {
+		if !inner.Enabled(r.Level) {
+			continue
+		}
+		if err := inner.Handle(r); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+func (h *AsyncHandler) report(err error) {
+	if err != nil {
+		// Nobody is waiting on the result anymore, stderr is all we've got
+		_, _ = fmt.Fprintf(os.Stderr, "Logging error: %v\n", err)
+	}
+}
@@ -0,0 +1,188 @@
+package logger_test
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/theHamdiz/it/logger"
+)
+
+// gateHandler blocks every Handle until the gate is opened, so we can fill
+// the async buffer on purpose
+type gateHandler struct {
+	gate chan struct{}
+	mu   sync.Mutex
+	msgs []string
+}
+
+func newGateHandler() *gateHandler {
+	return &gateHandler{gate: make(chan struct{})}
+}
+
+func (g *gateHandler) Enabled(logger.LogLevel) bool { return true }
+
+func (g *gateHandler) Handle(r logger.Record) error {
+	<-g.gate
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.msgs = append(g.msgs, r.Message)
+	return nil
+}
+
+func (g *gateHandler) messages() []string {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return append([]string(nil), g.msgs...)
+}
+
+func TestAsyncHandler_FlushWritesEverythingInOrder(t *testing.T) {
+	sink := &SyncWriter{}
+	logger_ := logger.NewLoggerWithLevelAndOutput(logger.LevelTrace, sink)
+	async := logger_.EnableAsync(logger.AsyncConfig{BufferSize: 16})
+	defer async.Close(context.Background())
+
+	for i := 0; i < 100; i++ {
+		logger_.Infof("message %03d", i)
+	}
+	if err := logger_.Flush(context.Background()); err != nil {
+		t.Fatalf("Flush() error = %v", err)
+	}
+
+	output := sink.String()
+	last := -1
+	for i := 0; i < 100; i++ {
+		idx := strings.Index(output, fmt.Sprintf("message %03d", i))
+		if idx < 0 || idx < last {
+			t.Fatalf("Expected message %d in order, output %q", i, output)
+		}
+		last = idx
+	}
+}
+
+func TestAsyncHandler_DropNewest(t *testing.T) {
+	inner := newGateHandler()
+	async := logger.NewAsyncHandler(logger.AsyncConfig{BufferSize: 2, Overflow: logger.OverflowDropNewest}, inner)
+	logger_ := logger.NewLoggerWithHandlers(logger.LevelTrace, async)
+
+	// The first record is grabbed by the flusher and stuck at the gate
+	logger_.Info("stuck")
+	waitFor(t, func() bool { return async.Len() == 0 })
+
+	logger_.Info("kept-1")
+	logger_.Info("kept-2")
+	logger_.Info("dropped")
+
+	if async.Dropped() != 1 {
+		t.Errorf("Expected 1 dropped record, got %d", async.Dropped())
+	}
+
+	close(inner.gate)
+	_ = async.Close(context.Background())
+
+	got := strings.Join(inner.messages(), ",")
+	if strings.Contains(got, "dropped,") || !strings.Contains(got, "kept-1,kept-2") {
+		t.Errorf("Unexpected delivery %q", got)
+	}
+	if !strings.Contains(got, "async logger dropped 1 records") {
+		t.Errorf("Expected a dropped-count record, got %q", got)
+	}
+}
+
+func TestAsyncHandler_DropOldest(t *testing.T) {
+	inner := newGateHandler()
+	async := logger.NewAsyncHandler(logger.AsyncConfig{BufferSize: 2, Overflow: logger.OverflowDropOldest}, inner)
+	logger_ := logger.NewLoggerWithHandlers(logger.LevelTrace, async)
+
+	logger_.Info("stuck")
+	waitFor(t, func() bool { return async.Len() == 0 })
+
+	logger_.Info("evicted")
+	logger_.Info("kept-1")
+	logger_.Info("kept-2")
+
+	close(inner.gate)
+	_ = async.Close(context.Background())
+
+	got := strings.Join(inner.messages(), ",")
+	if strings.Contains(got, "evicted") || !strings.Contains(got, "kept-1,kept-2") {
+		t.Errorf("Expected the oldest record to be evicted, got %q", got)
+	}
+	if async.Dropped() != 1 {
+		t.Errorf("Expected 1 dropped record, got %d", async.Dropped())
+	}
+}
+
+func TestAsyncHandler_BlockWaitsForRoom(t *testing.T) {
+	inner := newGateHandler()
+	async := logger.NewAsyncHandler(logger.AsyncConfig{BufferSize: 1, Overflow: logger.OverflowBlock}, inner)
+	logger_ := logger.NewLoggerWithHandlers(logger.LevelTrace, async)
+
+	logger_.Info("stuck")
+	waitFor(t, func() bool { return async.Len() == 0 })
+	logger_.Info("queued")
+
+	done := make(chan struct{})
+	go func() {
+		logger_.Info("blocked")
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		t.Fatal("Expected the caller to block on a full buffer")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	close(inner.gate)
+	<-done
+	_ = async.Close(context.Background())
+
+	if got := len(inner.messages()); got != 3 {
+		t.Errorf("Expected all 3 records with the block policy, got %d", got)
+	}
+}
+
+func TestAsyncHandler_CloseFallsBackToSync(t *testing.T) {
+	sink := &SyncWriter{}
+	logger_ := logger.NewLoggerWithLevelAndOutput(logger.LevelTrace, sink)
+	async := logger_.EnableAsync(logger.AsyncConfig{})
+
+	if err := async.Close(context.Background()); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	logger_.Info("straggler")
+	if !strings.Contains(sink.String(), "straggler") {
+		t.Errorf("Expected records after Close to be written synchronously, got %q", sink.String())
+	}
+}
+
+func TestAsyncHandler_FlushRespectsContext(t *testing.T) {
+	inner := newGateHandler()
+	async := logger.NewAsyncHandler(logger.AsyncConfig{}, inner)
+	logger_ := logger.NewLoggerWithHandlers(logger.LevelTrace, async)
+	logger_.Info("stuck")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := async.Flush(ctx); err != context.DeadlineExceeded {
+		t.Errorf("Expected DeadlineExceeded, got %v", err)
+	}
+
+	close(inner.gate)
+	_ = async.Close(context.Background())
+}
+
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("condition not met in time")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
@@ -0,0 +1,154 @@
+package audit_test
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/theHamdiz/it/logger/audit"
+)
+
+var key = []byte("correct horse battery staple")
+
+type ticket struct {
+	ID    int    `json:"id"`
+	Title string `json:"title"`
+}
+
+func writeTrail(t *testing.T, n int) []string {
+	t.Helper()
+	var buf bytes.Buffer
+	a, err := audit.New(&buf, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < n; i++ {
+		_, err := a.Record(audit.Event{
+			Actor:    "alice",
+			Action:   "ticket.update",
+			Resource: "ticket/42",
+			Outcome:  audit.OutcomeSuccess,
+			Metadata: map[string]any{"n": i, "big": uint64(1) << 60, "ticket": ticket{ID: 42, Title: "<b>bad\xffbytes</b>"}},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	lines := strings.SplitAfter(buf.String(), "\n")
+	return lines[:len(lines)-1]
+}
+
+func verify(lines []string) (audit.Entry, error) {
+	return audit.Verify(strings.NewReader(strings.Join(lines, "")), key)
+}
+
+func TestVerify_IntactChain(t *testing.T) {
+	lines := writeTrail(t, 5)
+
+	last, err := verify(lines)
+	if err != nil {
+		t.Fatalf("Expected an intact chain, got %v", err)
+	}
+	if last.Seq != 5 || last.Actor != "alice" || last.Outcome != audit.OutcomeSuccess {
+		t.Errorf("Unexpected last entry %+v", last)
+	}
+}
+
+func TestVerify_DetectsTampering(t *testing.T) {
+	lines := writeTrail(t, 5)
+	lines[2] = strings.Replace(lines[2], `"actor":"alice"`, `"actor":"mallory"`, 1)
+
+	_, err := verify(lines)
+	var verr *audit.VerifyError
+	if !errors.As(err, &verr) || !errors.Is(err, audit.ErrTampered) {
+		t.Fatalf("Expected ErrTampered, got %v", err)
+	}
+	if verr.Line != 3 || verr.Seq != 3 {
+		t.Errorf("Expected line 3, seq 3, got %+v", verr)
+	}
+}
+
+func TestVerify_DetectsMissingAndReordered(t *testing.T) {
+	lines := writeTrail(t, 5)
+
+	_, err := verify(append(append([]string{}, lines[:1]...), lines[2:]...))
+	var verr *audit.VerifyError
+	if !errors.As(err, &verr) || !errors.Is(err, audit.ErrMissing) || verr.Line != 2 || verr.Seq != 2 {
+		t.Errorf("Expected seq 2 missing on line 2, got %v", err)
+	}
+
+	lines[1], lines[2] = lines[2], lines[1]
+	if _, err := verify(lines); !errors.Is(err, audit.ErrMissing) {
+		t.Errorf("Expected reordering to be reported, got %v", err)
+	}
+}
+
+func TestVerify_WrongKeyAndGarbage(t *testing.T) {
+	lines := writeTrail(t, 2)
+
+	if _, err := audit.Verify(strings.NewReader(strings.Join(lines, "")), []byte("guess")); !errors.Is(err, audit.ErrTampered) {
+		t.Errorf("Expected a different key to fail, got %v", err)
+	}
+	if _, err := verify(append(lines, "not json\n")); !errors.Is(err, audit.ErrMalformed) {
+		t.Errorf("Expected ErrMalformed, got %v", err)
+	}
+	if _, err := verify([]string{strings.TrimSuffix(lines[0], "\n") + "{}\n"}); !errors.Is(err, audit.ErrMalformed) {
+		t.Errorf("Expected trailing data to be malformed, got %v", err)
+	}
+	if _, err := audit.New(&bytes.Buffer{}, nil); !errors.Is(err, audit.ErrNoKey) {
+		t.Errorf("Expected ErrNoKey, got %v", err)
+	}
+}
+
+func TestOpen_ResumesChain(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+
+	for i := 0; i < 2; i++ {
+		a, err := audit.Open(path, key)
+		if err != nil {
+			t.Fatalf("Open() error = %v", err)
+		}
+		for j := 0; j < 3; j++ {
+			if _, err := a.Record(audit.Event{Actor: "bob", Action: "login", Outcome: audit.OutcomeDenied}); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := a.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	last, err := audit.VerifyFile(path, key)
+	if err != nil {
+		t.Fatalf("Expected the resumed chain to verify, got %v", err)
+	}
+	if last.Seq != 6 {
+		t.Errorf("Expected 6 entries, got %d", last.Seq)
+	}
+
+	info, _ := os.Stat(path)
+	if info.Mode().Perm() != 0o600 {
+		t.Errorf("Expected the trail to be private, got %v", info.Mode().Perm())
+	}
+}
+
+func TestDefault(t *testing.T) {
+	defer audit.SetDefault(nil)
+	if _, err := audit.Record(audit.Event{Action: "nothing"}); err == nil {
+		t.Error("Expected an error without an audit trail")
+	}
+
+	path := filepath.Join(t.TempDir(), "audit.log")
+	if err := audit.SetFile(path, key); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := audit.Record(audit.Event{Actor: "carol", Action: "deploy", Outcome: audit.OutcomeSuccess}); err != nil {
+		t.Fatal(err)
+	}
+	if last, err := audit.VerifyFile(path, key); err != nil || last.Action != "deploy" {
+		t.Errorf("Expected the deploy entry, got %+v, %v", last, err)
+	}
+}
@@ -0,0 +1,77 @@
+package logger
+
+// ===================================================
+// Imports Area
+// ===================================================
+
+import "context"
+
+// ===================================================
+// Definitions Area
+// ===================================================
+
+// ctxKey is unexported so nobody else can stomp on our context value
+type ctxKey struct{}
+
+// ===================================================
+// Public Functions Area
+// ===================================================
+
+// With returns a child of the default logger carrying fields
+func With(fields map[string]any) *Logger {
+	return defaultLogger.With(fields)
+}
+
+// With returns a child logger that merges fields into every entry: into
+// StructuredLogEntry.Data for structured logs, and as key=value pairs for
+// plain lines. The child shares its parent's level and handlers, so
+// changing either on one of them changes both.
+func (l *Logger) With(fields map[string]any) *Logger {
+	child := &Logger{
+		level:        l.level,
+		output:       l.output,
+		onceMessages: l.onceMessages,
+		revert:       l.revert,
+		fields:       make(map[string]any, len(l.fields)+len(fields)),
+		name:         l.name,
+	}
+	for k, v := range l.fields {
+		child.fields[k] = v
+	}
+	for k, v := range fields {
+		child.fields[k] = v
+	}
+	return child
+}
+
+// Fields returns a copy of the fields this logger stamps on its entries
+func (l *Logger) Fields() map[string]any {
+	fields := make(map[string]any, len(l.fields))
+	for k, v := range l.fields {
+		fields[k] = v
+	}
+	return fields
+}
+
+// NewContext stashes l in ctx so code deep in the call stack can pick it
+// up with FromContext
+func NewContext(ctx context.Context, l *Logger) context.Context {
+	return context.WithValue(ctx, ctxKey{}, l)
+}
+
+// FromContext returns the logger stored in ctx, or the default logger when
+// there is none, so callers never have to nil-check
+func FromContext(ctx context.Context) *Logger {
+	if ctx != nil {
+		if l, ok := ctx.Value(ctxKey{}).(*Logger); ok && l != nil {
+			return l
+		}
+	}
+	return defaultLogger
+}
+
+// WithContext is the one-liner for request middleware: it derives a child of
+// the logger already in ctx and stores it back
+func WithContext(ctx context.Context, fields map[string]any) context.Context {
+	return NewContext(ctx, FromContext(ctx).With(fields))
+}
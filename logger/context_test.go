@@ -0,0 +1,79 @@
+package logger_test
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/theHamdiz/it/logger"
+)
+
+func TestLogger_WithStructuredFields(t *testing.T) {
+	logger_, buf := newTestLogger()
+	child := logger_.With(map[string]any{"request_id": "abc", "user_id": 7})
+
+	child.StructuredInfo("handled", map[string]any{"status": 200, "user_id": 8})
+
+	var entry map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+		t.Fatalf("Failed to unmarshal JSON: %v", err)
+	}
+	data := entry["data"].(map[string]interface{})
+	if data["request_id"] != "abc" {
+		t.Errorf("Expected request_id from With, got %v", data["request_id"])
+	}
+	if data["status"].(float64) != 200 {
+		t.Errorf("Expected status from call site, got %v", data["status"])
+	}
+	if data["user_id"].(float64) != 8 {
+		t.Errorf("Expected call-site data to win, got %v", data["user_id"])
+	}
+}
+
+func TestLogger_WithPlainFields(t *testing.T) {
+	logger_, buf := newTestLogger()
+	logger_.With(map[string]any{"b": 2}).With(map[string]any{"a": 1}).Info("hello")
+
+	if !strings.Contains(buf.String(), "hello a=1 b=2") {
+		t.Errorf("Expected sorted fields appended to the line, got %q", buf.String())
+	}
+}
+
+func TestLogger_WithDoesNotLeakIntoParent(t *testing.T) {
+	logger_, buf := newTestLogger()
+	_ = logger_.With(map[string]any{"secret": "child-only"})
+
+	logger_.Info("parent")
+	if strings.Contains(buf.String(), "child-only") {
+		t.Errorf("Expected parent to stay clean, got %q", buf.String())
+	}
+}
+
+func TestLogger_WithSharesLevel(t *testing.T) {
+	logger_, buf := newTestLogger()
+	child := logger_.With(map[string]any{"k": "v"})
+
+	logger_.SetLevel(logger.LevelError)
+	child.Info("muted")
+	if buf.Len() != 0 {
+		t.Errorf("Expected child to follow parent's level, got %q", buf.String())
+	}
+}
+
+func TestContext_RoundTrip(t *testing.T) {
+	logger_, buf := newTestLogger()
+	ctx := logger.NewContext(context.Background(), logger_)
+	ctx = logger.WithContext(ctx, map[string]any{"request_id": "r-1"})
+
+	logger.FromContext(ctx).Info("deep in the stack")
+	if !strings.Contains(buf.String(), "request_id=r-1") {
+		t.Errorf("Expected inherited request fields, got %q", buf.String())
+	}
+}
+
+func TestContext_FallsBackToDefault(t *testing.T) {
+	if logger.FromContext(context.Background()) != logger.DefaultLogger() {
+		t.Error("Expected default logger for an empty context")
+	}
+}
@@ -0,0 +1,177 @@
+package logger
+
+// ===================================================
+// Imports Area
+// ===================================================
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"strings"
+)
+
+// ===================================================
+// Definitions Area
+// ===================================================
+
+// ErrorInfo is how ErrorErr describes an error. Its JSON shape is part of
+// StructuredLogEntry and stays put, so dashboards can rely on it:
+//
+//	"error": {
+//	  "message": "...", "type": "*fs.PathError",
+//	  "causes": [{"depth": 1, "type": "...", "message": "..."}],
+//	  "stack":  [{"function": "...", "file": "...", "line": 42}]
+//	}
+type ErrorInfo struct {
+	Message string       `json:"message"`
+	Type    string       `json:"type"`
+	Causes  []ErrorCause `json:"causes,omitempty"`
+	Stack   []StackFrame `json:"stack,omitempty"`
+}
+
+// ErrorCause is one error found by unwrapping, in depth-first order.
+// Depth is 1 for what the top error wraps, 2 for what that wraps, and so on;
+// the branches of an errors.Join all share the same depth.
+type ErrorCause struct {
+	Depth   int    `json:"depth"`
+	Type    string `json:"type"`
+	Message string `json:"message"`
+}
+
+// StackFrame is one call in a captured stack, innermost first
+type StackFrame struct {
+	Function string `json:"function"`
+	File     string `json:"file"`
+	Line     int    `json:"line"`
+}
+
+// StackFilter decides which frames make it into ErrorErr stacks, true keeps
+type StackFilter func(StackFrame) bool
+
+// ===================================================
+// Declarations Area
+// ===================================================
+
+// Past this many causes we assume someone built a cycle and stop walking
+const maxErrorCauses = 64
+
+// ===================================================
+// Public Functions Area
+// ===================================================
+
+// ErrorErr logs err at ERROR as a structured entry with its whole cause
+// chain and the stack of the call site attached under "error". fields are
+// logged as data, like StructuredError.
+func (l *Logger) ErrorErr(err error, msg string, fields map[string]any) {
+	l.errorErr(context.Background(), err, msg, fields)
+}
+
+// SetStackFilter trims the stacks ErrorErr captures on the default logger
+func SetStackFilter(f StackFilter) {
+	defaultLogger.SetStackFilter(f)
+}
+
+// SetStackFilter trims the stacks ErrorErr captures, nil keeps every frame
+func (l *Logger) SetStackFilter(f StackFilter) {
+	l.updatePipeline(func(p *pipeline) {
+		p.stackFilter = f
+	})
+}
+
+// SkipFrames is a StackFilter dropping frames whose function starts with
+// one of prefixes, e.g. SkipFrames("runtime.", "testing.")
+func SkipFrames(prefixes ...string) StackFilter {
+	return func(f StackFrame) bool {
+		for _, p := range prefixes {
+			if strings.HasPrefix(f.Function, p) {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+// ===================================================
+// Private Functions Area
+// ===================================================
+
+// errorErr does the work for ErrorErr and ErrorErrCtx
+func (l *Logger) errorErr(ctx context.Context, err error, msg string, fields map[string]any) {
+	if !l.shouldLog(LevelError) {
+		return
+	}
+	r := l.record(LevelError, msg, fields, true, 2)
+	if err != nil {
+		r.Error = describeError(err)
+		r.Error.Stack = captureStack(2, l.pipeline().stackFilter)
+	}
+	l.stampTrace(ctx, &r)
+	l.dispatch(r)
+}
+
+// describeError records err and everything it wraps
+func describeError(err error) *ErrorInfo {
+	info := &ErrorInfo{Message: err.Error(), Type: fmt.Sprintf("%T", err)}
+
+	var walk func(e error, depth int)
+	walk = func(e error, depth int) {
+		var next []error
+		switch u := e.(type) {
+		case interface{ Unwrap() error }:
+			next = []error{u.Unwrap()}
+		case interface{ Unwrap() []error }:
+			next = u.Unwrap()
+		}
+		for _, n := range next {
+			if n == nil || len(info.Causes) >= maxErrorCauses {
+				continue
+			}
+			info.Causes = append(info.Causes, ErrorCause{Depth: depth, Type: fmt.Sprintf("%T", n), Message: n.Error()})
+			walk(n, depth+1)
+		}
+	}
+	walk(err, 1)
+	return info
+}
+
+// captureStack returns the whole stack above the caller of captureStack,
+// minus skip more frames. The buffer grows until nothing is cut off.
+func captureStack(skip int, filter StackFilter) []StackFrame {
+	pcs := make([]uintptr, 64)
+	for {
+		// Skip runtime.Callers and captureStack itself
+		n := runtime.Callers(skip+2, pcs)
+		if n < len(pcs) {
+			pcs = pcs[:n]
+			break
+		}
+		pcs = make([]uintptr, len(pcs)*2)
+	}
+
+	stack := make([]StackFrame, 0, len(pcs))
+	frames := runtime.CallersFrames(pcs)
+	for {
+		frame, more := frames.Next()
+		sf := StackFrame{Function: frame.Function, File: frame.File, Line: frame.Line}
+		if filter == nil || filter(sf) {
+			stack = append(stack, sf)
+		}
+		if !more {
+			break
+		}
+	}
+	return stack
+}
+
+// redactError scrubs the messages of a described error, leaving info alone
+func (r *Redactor) redactError(info *ErrorInfo) *ErrorInfo {
+	redacted := *info
+	redacted.Message = r.RedactString(info.Message)
+	redacted.Causes = make([]ErrorCause, len(info.Causes))
+	for i, c := range info.Causes {
+		c.Message = r.RedactString(c.Message)
+		redacted.Causes[i] = c
+	}
+	return &redacted
+}
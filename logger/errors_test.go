@@ -0,0 +1,139 @@
+package logger_test
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"strings"
+	"testing"
+
+	"github.com/theHamdiz/it/logger"
+)
+
+type errorEntry struct {
+	Message string            `json:"message"`
+	Data    map[string]any    `json:"data"`
+	Error   *logger.ErrorInfo `json:"error"`
+}
+
+func decodeErrorEntry(t *testing.T, b []byte) errorEntry {
+	t.Helper()
+	var entry errorEntry
+	if err := json.Unmarshal(b, &entry); err != nil {
+		t.Fatalf("Failed to unmarshal JSON %q: %v", b, err)
+	}
+	if entry.Error == nil {
+		t.Fatalf("Expected an error block, got %q", b)
+	}
+	return entry
+}
+
+func TestErrorErr_CauseChain(t *testing.T) {
+	logger_, buf := newTestLogger()
+
+	pathErr := &fs.PathError{Op: "open", Path: "/etc/app.conf", Err: fs.ErrNotExist}
+	err := fmt.Errorf("loading config: %w", errors.Join(pathErr, errors.New("fallback failed")))
+	logger_.ErrorErr(err, "startup failed", map[string]any{"attempt": 2})
+
+	entry := decodeErrorEntry(t, buf.Bytes())
+	if entry.Message != "startup failed" || entry.Data["attempt"] != float64(2) {
+		t.Errorf("Expected message and fields to survive, got %+v", entry)
+	}
+	if entry.Error.Message != err.Error() || entry.Error.Type != "*fmt.wrapError" {
+		t.Errorf("Unexpected top error %+v", entry.Error)
+	}
+
+	want := []logger.ErrorCause{
+		{Depth: 1, Type: "*errors.joinError", Message: pathErr.Error() + "\nfallback failed"},
+		{Depth: 2, Type: "*fs.PathError", Message: pathErr.Error()},
+		{Depth: 3, Type: "*errors.errorString", Message: fs.ErrNotExist.Error()},
+		{Depth: 2, Type: "*errors.errorString", Message: "fallback failed"},
+	}
+	if len(entry.Error.Causes) != len(want) {
+		t.Fatalf("Expected %d causes, got %+v", len(want), entry.Error.Causes)
+	}
+	for i, c := range want {
+		if entry.Error.Causes[i] != c {
+			t.Errorf("Cause %d: expected %+v, got %+v", i, c, entry.Error.Causes[i])
+		}
+	}
+}
+
+func TestErrorErr_FullStack(t *testing.T) {
+	logger_, buf := newTestLogger()
+
+	// Deep enough to blow through any small fixed buffer
+	var recurse func(n int)
+	recurse = func(n int) {
+		if n == 0 {
+			logger_.ErrorErr(errors.New("deep"), "down here", nil)
+			return
+		}
+		recurse(n - 1)
+	}
+	recurse(100)
+
+	entry := decodeErrorEntry(t, buf.Bytes())
+	stack := entry.Error.Stack
+	if len(stack) < 100 {
+		t.Fatalf("Expected the whole stack, got %d frames", len(stack))
+	}
+	if !strings.Contains(stack[0].Function, "TestErrorErr_FullStack") || !strings.HasSuffix(stack[0].File, "errors_test.go") {
+		t.Errorf("Expected the call site first, got %+v", stack[0])
+	}
+	if strings.Contains(stack[len(stack)-1].Function, "logger.(*Logger)") {
+		t.Errorf("Expected no logger frames, got %+v", stack[len(stack)-1])
+	}
+}
+
+func TestErrorErr_StackFilterAndRedaction(t *testing.T) {
+	logger_, buf := newTestLogger()
+	logger_.SetStackFilter(logger.SkipFrames("runtime.", "testing."))
+	logger_.SetRedactor(logger.DefaultRedactor())
+
+	logger_.ErrorErr(fmt.Errorf("login for bob@example.com: %w", errors.New("token eyJhbGciOiJIUzI1NiJ9.eyJzdWIiOiIxIn0.sig rejected")), "auth", nil)
+
+	entry := decodeErrorEntry(t, buf.Bytes())
+	for _, frame := range entry.Error.Stack {
+		if strings.HasPrefix(frame.Function, "runtime.") || strings.HasPrefix(frame.Function, "testing.") {
+			t.Errorf("Expected %s to be filtered out", frame.Function)
+		}
+	}
+	if strings.Contains(buf.String(), "example.com") || strings.Contains(buf.String(), "eyJ") {
+		t.Errorf("Expected error messages to be redacted, got %q", buf.String())
+	}
+}
+
+func TestErrorErr_OtherFormats(t *testing.T) {
+	logger_, buf := newTestLogger()
+	logger_.SetStackFilter(logger.SkipFrames("runtime.", "testing."))
+
+	logger_.SetFormat(logger.FormatLogfmt)
+	logger_.ErrorErr(fmt.Errorf("outer: %w", errors.New("inner")), "failed", nil)
+	if !strings.Contains(buf.String(), `error="outer: inner" error.type=*fmt.wrapError error.causes=`) {
+		t.Errorf("Expected error fields in logfmt, got %q", buf.String())
+	}
+
+	buf.Reset()
+	logger_.SetFormat(logger.FormatConsole)
+	logger_.ErrorErr(fmt.Errorf("outer: %w", errors.New("inner")), "failed", nil)
+	if !strings.Contains(buf.String(), "caused by: inner") || !strings.Contains(buf.String(), "at github.com/theHamdiz/it/logger_test.TestErrorErr_OtherFormats") {
+		t.Errorf("Expected causes and frames on the console, got %q", buf.String())
+	}
+}
+
+func TestErrorErr_NilAndLevel(t *testing.T) {
+	logger_, buf := newTestLogger()
+	logger_.ErrorErr(nil, "no error after all", nil)
+	if strings.Contains(buf.String(), `"error"`) || !strings.Contains(buf.String(), "no error after all") {
+		t.Errorf("Expected a plain structured entry for a nil error, got %q", buf.String())
+	}
+
+	buf.Reset()
+	logger_.SetLevel(logger.LevelFatal)
+	logger_.ErrorErr(errors.New("quiet"), "filtered", nil)
+	if buf.Len() != 0 {
+		t.Errorf("Expected the level to apply, got %q", buf.String())
+	}
+}
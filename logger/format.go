@@ -0,0 +1,325 @@
+package logger
+
+// ===================================================
+// Imports Area
+// ===================================================
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/fatih/color"
+)
+
+// ===================================================
+// Definitions Area
+// ===================================================
+
+// LogFormat names one of the built-in formatters, so the choice can live in
+// config files and environment variables
+type LogFormat string
+
+// LogfmtFormatter renders key=value lines that grep, awk and Loki all love
+type LogfmtFormatter struct{}
+
+// ConsoleFormatter is for humans staring at a terminal: timestamp, level,
+// caller and message, with fields lined up in a column after them
+type ConsoleFormatter struct {
+	// Messages shorter than this are padded so fields line up, defaults to 40
+	MessageWidth int
+	// Layout for the timestamp, defaults to "2006-01-02 15:04:05.000"
+	TimeFormat string
+	colors     map[LogLevel]*color.Color
+}
+
+// formatterSetter is implemented by handlers whose formatter can be swapped
+type formatterSetter interface {
+	SetFormatter(f Formatter)
+}
+
+// ===================================================
+// Declarations Area
+// ===================================================
+
+const (
+	// FormatText is the classic emoji text, with JSON for structured entries
+	FormatText LogFormat = "text"
+	// FormatJSON is one StructuredLogEntry per line, for everything
+	FormatJSON LogFormat = "json"
+	// FormatLogfmt is key=value pairs, for everything
+	FormatLogfmt LogFormat = "logfmt"
+	// FormatConsole is the aligned, human-friendly layout, for everything
+	FormatConsole LogFormat = "console"
+)
+
+const (
+	defaultConsoleMessageWidth = 40
+	defaultConsoleTimeFormat   = "2006-01-02 15:04:05.000"
+)
+
+// ===================================================
+// Public Functions Area
+// ===================================================
+
+// ParseLogFormat turns "json", "LOGFMT" and friends into a LogFormat
+func ParseLogFormat(s string) (LogFormat, error) {
+	switch format := LogFormat(strings.ToLower(strings.TrimSpace(s))); format {
+	case FormatText, FormatJSON, FormatLogfmt, FormatConsole:
+		return format, nil
+	default:
+		return "", fmt.Errorf("unknown log format %q", s)
+	}
+}
+
+// NewFormatter returns the formatter behind a LogFormat, falling back to
+// the classic text formatter for anything it doesn't recognize
+func NewFormatter(format LogFormat) Formatter {
+	switch format {
+	case FormatJSON:
+		return NewJSONFormatter()
+	case FormatLogfmt:
+		return NewLogfmtFormatter()
+	case FormatConsole:
+		return NewConsoleFormatter()
+	default:
+		return NewTextFormatter()
+	}
+}
+
+// SetLogFormat switches the default logger to format
+func SetLogFormat(format LogFormat) {
+	defaultLogger.SetFormat(format)
+}
+
+// SetFormat switches the handler SetOutput built (the default one included)
+// to format, and makes SetOutput use it from now on, so plain and
+// structured entries come out the same way. Handlers given their own
+// Formatter through SetHandlers or AddHandler keep it.
+func (l *Logger) SetFormat(format LogFormat) {
+	l.updatePipeline(func(p *pipeline) {
+		p.format = format
+		if p.output != nil {
+			p.output.SetFormatter(NewFormatter(format))
+		}
+	})
+}
+
+// Format returns the format SetOutput builds handlers with
+func (l *Logger) Format() LogFormat {
+	if format := l.pipeline().format; format != "" {
+		return format
+	}
+	return FormatText
+}
+
+// SetFormatter passes f on to every wrapped handler that can take it
+func (h *AsyncHandler) SetFormatter(f Formatter) {
+	for _, inner := range h.handlers {
+		if fs, ok := inner.(formatterSetter); ok {
+			fs.SetFormatter(f)
+		}
+	}
+}
+
+// NewLogfmtFormatter creates a logfmt formatter
+func NewLogfmtFormatter() *LogfmtFormatter {
+	return &LogfmtFormatter{}
+}
+
+func (f *LogfmtFormatter) Format(r Record) ([]byte, error) {
+	var b bytes.Buffer
+	writeLogfmtPair(&b, "time", r.Time.Format(time.RFC3339Nano))
+	writeLogfmtPair(&b, "level", r.Level.String())
+	writeLogfmtPair(&b, "msg", r.Message)
+	if caller := r.Caller(); caller != "" {
+		writeLogfmtPair(&b, "caller", caller)
+	}
+	if r.TraceID != "" {
+		writeLogfmtPair(&b, "trace_id", r.TraceID)
+		writeLogfmtPair(&b, "span_id", r.SpanID)
+	}
+	for _, kv := range flattenFields("", r.Data) {
+		writeLogfmtPair(&b, kv.key, kv.value)
+	}
+	if r.Error != nil {
+		writeLogfmtPair(&b, "error", r.Error.Message)
+		writeLogfmtPair(&b, "error.type", r.Error.Type)
+		if len(r.Error.Causes) > 0 {
+			writeLogfmtPair(&b, "error.causes", fieldString(r.Error.Causes))
+		}
+		if len(r.Error.Stack) > 0 {
+			writeLogfmtPair(&b, "error.stack", fieldString(r.Error.Stack))
+		}
+	}
+	b.WriteByte('\n')
+	return b.Bytes(), nil
+}
+
+// NewConsoleFormatter creates a console formatter with sensible widths
+func NewConsoleFormatter() *ConsoleFormatter {
+	return &ConsoleFormatter{
+		MessageWidth: defaultConsoleMessageWidth,
+		TimeFormat:   defaultConsoleTimeFormat,
+		colors: map[LogLevel]*color.Color{
+			LevelTrace:   color.New(color.FgMagenta),
+			LevelDebug:   color.New(color.FgBlue),
+			LevelInfo:    color.New(color.FgCyan),
+			LevelWarning: color.New(color.FgYellow),
+			LevelError:   color.New(color.FgRed),
+			LevelFatal:   color.New(color.FgRed, color.Bold),
+			LevelAudit:   color.New(color.FgGreen),
+		},
+	}
+}
+
+func (f *ConsoleFormatter) Format(r Record) ([]byte, error) {
+	var b bytes.Buffer
+
+	b.WriteString(r.Time.Format(f.TimeFormat))
+	b.WriteByte(' ')
+
+	level := fmt.Sprintf("%-7s", r.Level.String())
+	if c, ok := f.colors[r.Level]; ok {
+		level = c.Sprint(level)
+	}
+	b.WriteString(level)
+	b.WriteByte(' ')
+
+	if caller := r.Caller(); caller != "" {
+		b.WriteString(caller)
+		b.WriteByte(' ')
+	}
+
+	b.WriteString(r.Message)
+	fields := flattenFields("", r.Data)
+	if r.TraceID != "" {
+		fields = append(fields, fieldPair{"trace_id", r.TraceID}, fieldPair{"span_id", r.SpanID})
+	}
+	if len(fields) > 0 {
+		if pad := f.MessageWidth - utf8.RuneCountInString(r.Message); pad > 0 {
+			b.WriteString(strings.Repeat(" ", pad))
+		}
+		for _, kv := range fields {
+			b.WriteByte(' ')
+			writeLogfmtPair(&b, kv.key, kv.value)
+		}
+	}
+	b.WriteByte('\n')
+
+	// Errors get the multi-line treatment, that's what humans expect of them
+	if r.Error != nil {
+		fmt.Fprintf(&b, "    error: %s (%s)\n", r.Error.Message, r.Error.Type)
+		for _, c := range r.Error.Causes {
+			fmt.Fprintf(&b, "    %scaused by: %s (%s)\n", strings.Repeat("  ", c.Depth), c.Message, c.Type)
+		}
+		for _, frame := range r.Error.Stack {
+			fmt.Fprintf(&b, "        at %s (%s:%d)\n", frame.Function, frame.File, frame.Line)
+		}
+	}
+	return b.Bytes(), nil
+}
+
+// ===================================================
+// Private Functions Area
+// ===================================================
+
+type fieldPair struct {
+	key   string
+	value string
+}
+
+// flattenFields turns nested maps into dotted keys, sorted so lines diff nicely
+func flattenFields(prefix string, data map[string]any) []fieldPair {
+	keys := make([]string, 0, len(data))
+	for k := range data {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var pairs []fieldPair
+	for _, k := range keys {
+		key := k
+		if prefix != "" {
+			key = prefix + "." + k
+		}
+		if nested, ok := data[k].(map[string]any); ok && len(nested) > 0 {
+			pairs = append(pairs, flattenFields(key, nested)...)
+			continue
+		}
+		pairs = append(pairs, fieldPair{key: key, value: fieldString(data[k])})
+	}
+	return pairs
+}
+
+// fieldString renders a value the way a human would want to read it
+func fieldString(v any) string {
+	switch val := v.(type) {
+	case nil:
+		return "null"
+	case string:
+		return val
+	case error:
+		return methodString(v, val.Error)
+	case time.Time:
+		return val.Format(time.RFC3339Nano)
+	case time.Duration:
+		return val.String()
+	case fmt.Stringer:
+		return methodString(v, val.String)
+	case bool, int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32, float64:
+		return fmt.Sprint(val)
+	}
+	if b, err := json.Marshal(v); err == nil {
+		return string(b)
+	}
+	return fmt.Sprintf("%+v", v)
+}
+
+// methodString calls v's Error or String method, which a typed nil pointer
+// turns into a panic. Those come out as "null", like JSON has it; any other
+// panicking method gets fmt's take on it instead of taking the caller down.
+func methodString(v any, method func() string) (s string) {
+	defer func() {
+		if r := recover(); r != nil {
+			if rv := reflect.ValueOf(v); rv.Kind() == reflect.Pointer && rv.IsNil() {
+				s = "null"
+				return
+			}
+			s = fmt.Sprint(v)
+		}
+	}()
+	return method()
+}
+
+// writeLogfmtPair appends key=value, quoting the value only when it must
+func writeLogfmtPair(b *bytes.Buffer, key, value string) {
+	if b.Len() > 0 && b.Bytes()[b.Len()-1] != ' ' {
+		b.WriteByte(' ')
+	}
+	b.WriteString(key)
+	b.WriteByte('=')
+	if needsQuoting(value) {
+		b.WriteString(strconv.Quote(value))
+	} else {
+		b.WriteString(value)
+	}
+}
+
+func needsQuoting(s string) bool {
+	if s == "" {
+		return true
+	}
+	for _, r := range s {
+		if r <= ' ' || r == '=' || r == '"' || r == utf8.RuneError || r == 0x7f {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,172 @@
+package logger_test
+
+import (
+	"encoding/json"
+	"errors"
+	"net/url"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/theHamdiz/it/logger"
+)
+
+func TestParseLogFormat(t *testing.T) {
+	for in, want := range map[string]logger.LogFormat{
+		"json":     logger.FormatJSON,
+		" LOGFMT ": logger.FormatLogfmt,
+		"Console":  logger.FormatConsole,
+		"text":     logger.FormatText,
+	} {
+		got, err := logger.ParseLogFormat(in)
+		if err != nil || got != want {
+			t.Errorf("ParseLogFormat(%q) = %q, %v; want %q", in, got, err, want)
+		}
+	}
+	if _, err := logger.ParseLogFormat("xml"); err == nil {
+		t.Error("Expected an error for an unknown format")
+	}
+}
+
+func TestLogfmtFormatter(t *testing.T) {
+	when := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	out, err := logger.NewLogfmtFormatter().Format(logger.Record{
+		Time:    when,
+		Level:   logger.LevelWarning,
+		Message: "disk almost full",
+		Data: map[string]any{
+			"path":  "/var/log",
+			"free":  0.05,
+			"err":   errors.New("no space"),
+			"empty": "",
+			"disk":  map[string]any{"id": "sda1", "mount": "/"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Format failed: %v", err)
+	}
+
+	want := `time=2026-01-02T03:04:05Z level=WARNING msg="disk almost full" ` +
+		`disk.id=sda1 disk.mount=/ empty="" err="no space" free=0.05 path=/var/log` + "\n"
+	if string(out) != want {
+		t.Errorf("Expected\n%q\ngot\n%q", want, out)
+	}
+}
+
+func TestConsoleFormatter_AlignsFields(t *testing.T) {
+	defer func(prev bool) { color.NoColor = prev }(color.NoColor)
+	color.NoColor = true
+
+	f := logger.NewConsoleFormatter()
+	when := time.Date(2026, 1, 2, 3, 4, 5, 6_000_000, time.UTC)
+
+	short, _ := f.Format(logger.Record{Time: when, Level: logger.LevelInfo, Message: "hi", Data: map[string]any{"a": 1}})
+	long, _ := f.Format(logger.Record{Time: when, Level: logger.LevelError, Message: "something longer", Data: map[string]any{"a": 2}})
+
+	if !strings.HasPrefix(string(short), "2026-01-02 03:04:05.006 INFO    hi") {
+		t.Errorf("Unexpected console line %q", short)
+	}
+	if strings.Index(string(short), "a=1") != strings.Index(string(long), "a=2") {
+		t.Errorf("Expected fields to line up:\n%s%s", short, long)
+	}
+
+	bare, _ := f.Format(logger.Record{Time: when, Level: logger.LevelInfo, Message: "no fields"})
+	if strings.HasSuffix(strings.TrimSuffix(string(bare), "\n"), " ") {
+		t.Errorf("Expected no trailing padding without fields, got %q", bare)
+	}
+}
+
+func TestLogger_SetFormatAppliesToBothPaths(t *testing.T) {
+	logger_, buf := newTestLogger()
+	logger_.SetFormat(logger.FormatLogfmt)
+
+	logger_.Info("plain")
+	logger_.StructuredInfo("structured", map[string]any{"user": "bob"})
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("Expected 2 lines, got %q", buf.String())
+	}
+	pattern := regexp.MustCompile(`^time=\S+ level=INFO msg=\S+`)
+	for _, line := range lines {
+		if !pattern.MatchString(line) {
+			t.Errorf("Expected a logfmt line, got %q", line)
+		}
+	}
+	if !strings.HasSuffix(lines[1], "user=bob") {
+		t.Errorf("Expected structured data as fields, got %q", lines[1])
+	}
+}
+
+func TestLogger_SetFormatSurvivesSetOutput(t *testing.T) {
+	logger_, _ := newTestLogger()
+	logger_.SetFormat(logger.FormatJSON)
+
+	sink := &testWriter{}
+	logger_.SetOutput(sink)
+	logger_.Info("plain but json")
+
+	var entry map[string]interface{}
+	if err := json.Unmarshal(sink.Bytes(), &entry); err != nil {
+		t.Fatalf("Expected JSON after SetOutput, got %q", sink.String())
+	}
+	if logger_.Format() != logger.FormatJSON {
+		t.Errorf("Expected Format() to report json, got %q", logger_.Format())
+	}
+}
+
+func TestLogger_SetFormatKeepsOwnFormatters(t *testing.T) {
+	logger_, buf := newTestLogger()
+	sink := &testWriter{}
+	logger_.AddHandler(logger.NewWriterHandler(sink, logger.NewJSONFormatter(), logger.LevelTrace))
+
+	logger_.SetFormat(logger.FormatLogfmt)
+	logger_.Info("mixed")
+
+	if !regexp.MustCompile(`^time=\S+ level=INFO msg=mixed`).MatchString(buf.String()) {
+		t.Errorf("Expected the SetOutput handler to switch to logfmt, got %q", buf.String())
+	}
+	var entry map[string]interface{}
+	if err := json.Unmarshal(sink.Bytes(), &entry); err != nil {
+		t.Errorf("Expected the JSON handler to stay JSON, got %q", sink.String())
+	}
+}
+
+// panicky is an error whose Error method doesn't survive a nil receiver
+type panicky struct{ msg string }
+
+func (p *panicky) Error() string { return p.msg }
+
+func TestFormatters_TypedNilPointers(t *testing.T) {
+	defer func(prev bool) { color.NoColor = prev }(color.NoColor)
+	color.NoColor = true
+
+	record := logger.Record{
+		Time:    time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC),
+		Level:   logger.LevelInfo,
+		Message: "req",
+		Data: map[string]any{
+			"url": (*url.URL)(nil),
+			"err": (*panicky)(nil),
+		},
+	}
+	// Text goes through fmt, which has its own word for it
+	for format, want := range map[logger.LogFormat]string{
+		logger.FormatText:    "url=<nil>",
+		logger.FormatJSON:    `"url":null`,
+		logger.FormatLogfmt:  "url=null",
+		logger.FormatConsole: "url=null",
+	} {
+		t.Run(string(format), func(t *testing.T) {
+			out, err := logger.NewFormatter(format).Format(record)
+			if err != nil {
+				t.Fatalf("Format failed: %v", err)
+			}
+			if !strings.Contains(string(out), want) {
+				t.Errorf("Expected %q in %q", want, out)
+			}
+		})
+	}
+}
@@ -0,0 +1,297 @@
+package logger
+
+// ===================================================
+// Imports Area
+// ===================================================
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/fatih/color"
+)
+
+// ===================================================
+// Definitions Area
+// ===================================================
+
+// Record is a single log entry on its way to the handlers
+type Record struct {
+	Time    time.Time
+	Level   LogLevel
+	Message string
+	// Data holds the structured payload, nil for plain messages without fields
+	Data map[string]any
+	// Structured is set when the entry came through the StructuredLog family
+	Structured bool
+	// PC is the program counter of the call site, zero if unknown
+	PC uintptr
+	// Error is set by ErrorErr, with the cause chain and stack
+	Error *ErrorInfo
+	// TraceID and SpanID come from the context of the Ctx methods, empty otherwise
+	TraceID string
+	SpanID  string
+}
+
+// Formatter turns a record into the bytes a sink will receive
+type Formatter interface {
+	Format(r Record) ([]byte, error)
+}
+
+// Handler is one destination for log records. A Logger fans every record out
+// to all of its handlers, each of which applies its own level threshold.
+type Handler interface {
+	Enabled(level LogLevel) bool
+	Handle(r Record) error
+}
+
+// WriterHandler is the bread-and-butter handler: a formatter glued to an io.Writer
+type WriterHandler struct {
+	level atomic.Int32
+	// holds formatterHolder
+	formatter atomic.Value
+	// holds LogWriter
+	output atomic.Value
+	// keeps concurrent records from interleaving on the sink
+	mu sync.Mutex
+}
+
+// formatterHolder wraps a Formatter to maintain type consistency with atomic.Value
+type formatterHolder struct {
+	f Formatter
+}
+
+// TextFormatter is the classic emoji-prefixed, colored output for plain
+// messages. Structured records keep their JSON shape.
+type TextFormatter struct {
+	colors struct {
+		magenta *color.Color
+		blue    *color.Color
+		red     *color.Color
+		cyan    *color.Color
+		yellow  *color.Color
+	}
+}
+
+// JSONFormatter renders every record, plain or structured, as a StructuredLogEntry
+type JSONFormatter struct{}
+
+// pipeline is what a Logger stores in its output atomic.Value
+type pipeline struct {
+	handlers []Handler
+	sampler  *sampler
+	redactor *Redactor
+	// trims the stacks ErrorErr captures
+	stackFilter StackFilter
+	// finds spans for the Ctx methods, nil reads ContextWithSpan
+	extractor TraceExtractor
+	// what SetOutput builds handlers with, empty means FormatText
+	format LogFormat
+	// the handler SetOutput built, the only one SetFormat gets to touch
+	output *WriterHandler
+}
+
+// ===================================================
+// Public Functions Area
+// ===================================================
+
+// NewWriterHandler creates a handler writing records formatted by f to w,
+// dropping anything below level
+func NewWriterHandler(w io.Writer, f Formatter, level LogLevel) *WriterHandler {
+	h := &WriterHandler{}
+	h.formatter.Store(formatterHolder{f})
+	h.level.Store(int32(level))
+	h.output.Store(LogWriter{w})
+	return h
+}
+
+func (h *WriterHandler) Enabled(level LogLevel) bool {
+	return LogLevel(h.level.Load()) <= level
+}
+
+func (h *WriterHandler) Handle(r Record) error {
+	b, err := h.Formatter().Format(r)
+	if err != nil {
+		return err
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	_, err = h.Writer().Write(b)
+	return err
+}
+
+// SetLevel changes the threshold of this handler only
+func (h *WriterHandler) SetLevel(level LogLevel) {
+	h.level.Store(int32(level))
+}
+
+func (h *WriterHandler) Level() LogLevel {
+	return LogLevel(h.level.Load())
+}
+
+// SetOutput swaps the sink without touching the formatter
+func (h *WriterHandler) SetOutput(w io.Writer) {
+	h.output.Store(LogWriter{w})
+}
+
+func (h *WriterHandler) Writer() LogWriter {
+	return h.output.Load().(LogWriter)
+}
+
+// SetFormatter swaps the formatter without touching the sink
+func (h *WriterHandler) SetFormatter(f Formatter) {
+	h.formatter.Store(formatterHolder{f})
+}
+
+func (h *WriterHandler) Formatter() Formatter {
+	return h.formatter.Load().(formatterHolder).f
+}
+
+// NewTextFormatter creates the formatter the default logger has always used
+func NewTextFormatter() *TextFormatter {
+	f := &TextFormatter{}
+	f.colors.magenta = color.New(color.FgMagenta)
+	f.colors.blue = color.New(color.FgBlue)
+	f.colors.red = color.New(color.FgRed)
+	f.colors.cyan = color.New(color.FgCyan)
+	f.colors.yellow = color.New(color.FgYellow)
+	return f
+}
+
+func (f *TextFormatter) Format(r Record) ([]byte, error) {
+	if r.Structured {
+		b, err := encodeEntry(r)
+		if err == nil {
+			return b, nil
+		}
+		// Same thing we always did: complain loudly instead of losing the entry silently
+		r = Record{Level: LevelError, Message: fmt.Sprintf("Failed to encode structured log: %v", err)}
+	}
+
+	// Still keeping our emoji-based logging because we're not monsters
+	line := fmt.Sprintf("%s %s%s%s\n", getLevelPrefix(r.Level), r.Message, formatFields(r.Data), formatTrace(r))
+
+	switch r.Level {
+	case LevelTrace:
+		line = f.colors.magenta.Sprint(line)
+	case LevelDebug:
+		line = f.colors.blue.Sprint(line)
+	case LevelInfo:
+		line = f.colors.cyan.Sprint(line)
+	case LevelWarning:
+		line = f.colors.yellow.Sprint(line)
+	case LevelError, LevelFatal:
+		line = f.colors.red.Sprint(line)
+	}
+	return []byte(line), nil
+}
+
+// NewJSONFormatter creates a formatter producing one JSON object per line
+func NewJSONFormatter() *JSONFormatter {
+	return &JSONFormatter{}
+}
+
+func (f *JSONFormatter) Format(r Record) ([]byte, error) {
+	return encodeEntry(r)
+}
+
+// Caller resolves the call site as "file.go:line", or "" when unknown
+func (r Record) Caller() string {
+	if r.PC == 0 {
+		return ""
+	}
+	frame, _ := runtime.CallersFrames([]uintptr{r.PC}).Next()
+	if frame.File == "" {
+		return ""
+	}
+	return fmt.Sprintf("%s:%d", filepath.Base(frame.File), frame.Line)
+}
+
+// ===================================================
+// Private Functions Area
+// ===================================================
+
+// write hands r to every handler that wants it
+func (p pipeline) write(r Record) {
+	for _, h := range p.handlers {
+		if !h.Enabled(r.Level) {
+			continue
+		}
+		if err := h.Handle(r); err != nil {
+			// If we can't log, we're probably in trouble, but let's try one last time
+			_, _ = fmt.Fprintf(os.Stderr, "Logging error: %v\n", err)
+		}
+	}
+}
+
+// encodeEntry renders a record in the StructuredLogEntry JSON shape
+func encodeEntry(r Record) ([]byte, error) {
+	entry := structuredLogPool.Get().(*StructuredLogEntry)
+	defer structuredLogPool.Put(entry)
+
+	// Reset the entry
+	entry.Timestamp = r.Time
+	entry.Level = r.Level.String()
+	entry.Message = r.Message
+	entry.Error = r.Error
+	entry.TraceID = r.TraceID
+	entry.SpanID = r.SpanID
+	clear(entry.Data)
+	for k, v := range r.Data {
+		entry.Data[k] = v
+	}
+
+	// Add caller info in debug level
+	entry.Caller = ""
+	if r.Level <= LevelDebug {
+		entry.Caller = r.Caller()
+	}
+
+	buf := bufferPool.Get().(*bytes.Buffer)
+	buf.Reset()
+	defer bufferPool.Put(buf)
+
+	enc := json.NewEncoder(buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(entry); err != nil {
+		return nil, err
+	}
+	// The buffer goes back to the pool, the bytes go to the caller
+	return append([]byte(nil), buf.Bytes()...), nil
+}
+
+// formatTrace renders the trace IDs the way formatFields renders fields
+func formatTrace(r Record) string {
+	if r.TraceID == "" {
+		return ""
+	}
+	return fmt.Sprintf(" trace_id=%s span_id=%s", r.TraceID, r.SpanID)
+}
+
+// formatFields renders data as " key=value" pairs in a stable order
+func formatFields(data map[string]any) string {
+	if len(data) == 0 {
+		return ""
+	}
+	keys := make([]string, 0, len(data))
+	for k := range data {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var b bytes.Buffer
+	for _, k := range keys {
+		_, _ = fmt.Fprintf(&b, " %s=%v", k, data[k])
+	}
+	return b.String()
+}
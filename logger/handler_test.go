@@ -0,0 +1,148 @@
+package logger_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/theHamdiz/it/logger"
+)
+
+func TestLogger_FanOutWithPerHandlerLevels(t *testing.T) {
+	console := &bytes.Buffer{}
+	file := &bytes.Buffer{}
+
+	logger_ := logger.NewLoggerWithHandlers(logger.LevelDebug,
+		logger.NewWriterHandler(console, logger.NewTextFormatter(), logger.LevelInfo),
+		logger.NewWriterHandler(file, logger.NewJSONFormatter(), logger.LevelDebug),
+	)
+
+	logger_.Debug("only the file cares")
+	if console.Len() != 0 {
+		t.Errorf("Expected console to skip DEBUG, got %q", console.String())
+	}
+	if !strings.Contains(file.String(), "only the file cares") {
+		t.Errorf("Expected file to receive DEBUG, got %q", file.String())
+	}
+
+	file.Reset()
+	logger_.Info("everyone hears this")
+	if !strings.Contains(console.String(), "[✅️ INFO] everyone hears this") {
+		t.Errorf("Expected emoji text on console, got %q", console.String())
+	}
+
+	var entry map[string]interface{}
+	if err := json.Unmarshal(file.Bytes(), &entry); err != nil {
+		t.Fatalf("Expected JSON in file, got %q: %v", file.String(), err)
+	}
+	if entry["level"] != "INFO" || entry["message"] != "everyone hears this" {
+		t.Errorf("Unexpected JSON entry: %v", entry)
+	}
+}
+
+func TestLogger_LoggerLevelGatesHandlers(t *testing.T) {
+	buf := &bytes.Buffer{}
+	logger_ := logger.NewLoggerWithHandlers(logger.LevelWarning,
+		logger.NewWriterHandler(buf, logger.NewTextFormatter(), logger.LevelTrace),
+	)
+
+	logger_.Info("too quiet")
+	if buf.Len() != 0 {
+		t.Errorf("Expected logger level to win, got %q", buf.String())
+	}
+}
+
+func TestLogger_SetOutputReplacesHandlers(t *testing.T) {
+	first := &bytes.Buffer{}
+	second := &bytes.Buffer{}
+
+	logger_ := logger.NewLoggerWithHandlers(logger.LevelInfo,
+		logger.NewWriterHandler(first, logger.NewJSONFormatter(), logger.LevelInfo),
+	)
+	logger_.SetOutput(second)
+
+	if got := len(logger_.Handlers()); got != 1 {
+		t.Fatalf("Expected a single handler after SetOutput, got %d", got)
+	}
+
+	logger_.Info("hello")
+	if first.Len() != 0 {
+		t.Errorf("Expected old handler to be gone, got %q", first.String())
+	}
+	if !strings.Contains(second.String(), "hello") {
+		t.Errorf("Expected new output to receive the message, got %q", second.String())
+	}
+}
+
+func TestLogger_AddHandler(t *testing.T) {
+	logger_, buf := newTestLogger()
+	extra := &bytes.Buffer{}
+	logger_.AddHandler(logger.NewWriterHandler(extra, logger.NewJSONFormatter(), logger.LevelTrace))
+
+	logger_.Warn("twice")
+	if !strings.Contains(buf.String(), "twice") || !strings.Contains(extra.String(), "twice") {
+		t.Errorf("Expected both handlers to receive the message, got %q and %q", buf.String(), extra.String())
+	}
+}
+
+func TestLogger_ConcurrentPipelineChanges(t *testing.T) {
+	logger_ := logger.NewLoggerWithHandlers(logger.LevelInfo)
+	redactor := logger.DefaultRedactor()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			logger_.AddHandler(logger.NewWriterHandler(&bytes.Buffer{}, logger.NewTextFormatter(), logger.LevelInfo))
+		}()
+		go func() {
+			defer wg.Done()
+			logger_.SetRedactor(redactor)
+		}()
+	}
+	wg.Wait()
+
+	if got := len(logger_.Handlers()); got != 50 {
+		t.Errorf("Expected every AddHandler to stick, got %d handlers", got)
+	}
+	if logger_.Redactor() != redactor {
+		t.Error("Expected the redactor to survive racing AddHandler calls")
+	}
+}
+
+func TestWriterHandler_SetLevel(t *testing.T) {
+	buf := &bytes.Buffer{}
+	h := logger.NewWriterHandler(buf, logger.NewTextFormatter(), logger.LevelError)
+	logger_ := logger.NewLoggerWithHandlers(logger.LevelTrace, h)
+
+	logger_.Warn("dropped")
+	h.SetLevel(logger.LevelWarning)
+	logger_.Warn("kept")
+
+	if strings.Contains(buf.String(), "dropped") || !strings.Contains(buf.String(), "kept") {
+		t.Errorf("Unexpected output after SetLevel: %q", buf.String())
+	}
+	if h.Level() != logger.LevelWarning {
+		t.Errorf("Expected level WARNING, got %v", h.Level())
+	}
+}
+
+func TestJSONFormatter_CallerAtDebug(t *testing.T) {
+	buf := &bytes.Buffer{}
+	logger_ := logger.NewLoggerWithHandlers(logger.LevelTrace,
+		logger.NewWriterHandler(buf, logger.NewJSONFormatter(), logger.LevelTrace),
+	)
+
+	logger_.Debugf("where am %s", "I")
+
+	var entry logger.StructuredLogEntry
+	if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+		t.Fatalf("Failed to unmarshal JSON: %v", err)
+	}
+	if !strings.HasPrefix(entry.Caller, "handler_test.go:") {
+		t.Errorf("Expected caller to point at the test, got %q", entry.Caller)
+	}
+}
@@ -0,0 +1,212 @@
+package logger
+
+// ===================================================
+// Imports Area
+// ===================================================
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// ===================================================
+// Definitions Area
+// ===================================================
+
+// JournaldConfig says how to talk to systemd-journald
+type JournaldConfig struct {
+	// The journal's native socket, defaults to /run/systemd/journal/socket
+	Path string
+	// SYSLOG_IDENTIFIER, defaults to the executable's name
+	Identifier string
+	// Records below this are dropped
+	Level LogLevel
+}
+
+// JournaldHandler speaks journald's native protocol, so Data survives as
+// real journal fields you can filter on with journalctl KEY=value
+type JournaldHandler struct {
+	config JournaldConfig
+	level  atomic.Int32
+
+	mu   sync.Mutex
+	conn *net.UnixConn
+	addr *net.UnixAddr
+}
+
+// ===================================================
+// Declarations Area
+// ===================================================
+
+const defaultJournaldSocket = "/run/systemd/journal/socket"
+
+// Journal field names are uppercase ASCII, digits and underscores, at most 64 long
+const maxJournalFieldName = 64
+
+// reservedJournalFields are the fields the entry itself is made of, and the
+// ones journald reads meaning into. Data keys that land on one of them get
+// a DATA_ prefix instead of overwriting it.
+var reservedJournalFields = map[string]bool{
+	"MESSAGE": true, "MESSAGE_ID": true, "PRIORITY": true, "LEVEL": true,
+	"CODE_FILE": true, "CODE_LINE": true, "CODE_FUNC": true,
+	"TRACE_ID": true, "SPAN_ID": true, "ERROR": true, "ERROR_TYPE": true,
+	"ERRNO": true, "SYSLOG_IDENTIFIER": true, "SYSLOG_FACILITY": true,
+	"SYSLOG_PID": true, "SYSLOG_TIMESTAMP": true, "SYSLOG_RAW": true,
+	"INVOCATION_ID": true, "USER_INVOCATION_ID": true, "DOCUMENTATION": true,
+	"TID": true, "UNIT": true, "USER_UNIT": true,
+}
+
+// ===================================================
+// Public Functions Area
+// ===================================================
+
+// NewJournaldHandler opens a datagram socket towards the journal. journald
+// not running is only noticed by the first Handle, as datagrams don't dial.
+func NewJournaldHandler(config JournaldConfig) (*JournaldHandler, error) {
+	if config.Path == "" {
+		config.Path = defaultJournaldSocket
+	}
+	if config.Identifier == "" {
+		config.Identifier = filepath.Base(os.Args[0])
+	}
+
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Net: "unixgram"})
+	if err != nil {
+		return nil, fmt.Errorf("journald: %w", err)
+	}
+	h := &JournaldHandler{
+		config: config,
+		conn:   conn,
+		addr:   &net.UnixAddr{Name: config.Path, Net: "unixgram"},
+	}
+	h.level.Store(int32(config.Level))
+	return h, nil
+}
+
+func (h *JournaldHandler) Enabled(level LogLevel) bool {
+	return LogLevel(h.level.Load()) <= level
+}
+
+// Handle sends r as one datagram. Entries too big for a datagram fail
+// instead of taking the memfd detour journald offers for them.
+func (h *JournaldHandler) Handle(r Record) error {
+	msg := h.encode(r)
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.conn == nil {
+		return fmt.Errorf("journald: %w", net.ErrClosed)
+	}
+	if _, err := h.conn.WriteToUnix(msg, h.addr); err != nil {
+		return fmt.Errorf("journald: %w", err)
+	}
+	return nil
+}
+
+// SetLevel changes the threshold of this handler only
+func (h *JournaldHandler) SetLevel(level LogLevel) {
+	h.level.Store(int32(level))
+}
+
+func (h *JournaldHandler) Level() LogLevel {
+	return LogLevel(h.level.Load())
+}
+
+// Close releases the socket
+func (h *JournaldHandler) Close() error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.conn == nil {
+		return nil
+	}
+	err := h.conn.Close()
+	h.conn = nil
+	return err
+}
+
+// ===================================================
+// Private Functions Area
+// ===================================================
+
+// encode renders r in the native protocol, one field after the other
+func (h *JournaldHandler) encode(r Record) []byte {
+	var b bytes.Buffer
+	writeJournalField(&b, "MESSAGE", r.Message)
+	writeJournalField(&b, "PRIORITY", strconv.Itoa(syslogSeverity(r.Level)))
+	writeJournalField(&b, "SYSLOG_IDENTIFIER", h.config.Identifier)
+	writeJournalField(&b, "LEVEL", r.Level.String())
+
+	if r.PC != 0 {
+		frame, _ := runtime.CallersFrames([]uintptr{r.PC}).Next()
+		if frame.File != "" {
+			writeJournalField(&b, "CODE_FILE", frame.File)
+			writeJournalField(&b, "CODE_LINE", strconv.Itoa(frame.Line))
+			writeJournalField(&b, "CODE_FUNC", frame.Function)
+		}
+	}
+	if r.TraceID != "" {
+		writeJournalField(&b, "TRACE_ID", r.TraceID)
+		writeJournalField(&b, "SPAN_ID", r.SpanID)
+	}
+	if r.Error != nil {
+		writeJournalField(&b, "ERROR", r.Error.Message)
+		writeJournalField(&b, "ERROR_TYPE", r.Error.Type)
+	}
+	for _, kv := range flattenFields("", r.Data) {
+		writeJournalField(&b, journalFieldName(kv.key), kv.value)
+	}
+	return b.Bytes()
+}
+
+// writeJournalField writes KEY=value, or the length-prefixed binary form
+// when value has a newline in it
+func writeJournalField(b *bytes.Buffer, key, value string) {
+	b.WriteString(key)
+	if !strings.ContainsRune(value, '\n') {
+		b.WriteByte('=')
+		b.WriteString(value)
+		b.WriteByte('\n')
+		return
+	}
+	b.WriteByte('\n')
+	_ = binary.Write(b, binary.LittleEndian, uint64(len(value)))
+	b.WriteString(value)
+	b.WriteByte('\n')
+}
+
+// journalFieldName turns a data key into something journald accepts. A
+// leading underscore is reserved for fields journald sets itself, so
+// nobody gets to forge _PID, and a key named like one of the entry's own
+// fields comes out as DATA_MESSAGE rather than a second MESSAGE.
+func journalFieldName(key string) string {
+	name := strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z':
+			return r - 'a' + 'A'
+		case r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+			return r
+		default:
+			return '_'
+		}
+	}, key)
+	name = strings.TrimLeft(name, "_")
+	if name == "" || (name[0] >= '0' && name[0] <= '9') {
+		name = "FIELD_" + name
+	}
+	if reservedJournalFields[name] {
+		name = "DATA_" + name
+	}
+	if len(name) > maxJournalFieldName {
+		name = name[:maxJournalFieldName]
+	}
+	return name
+}
@@ -0,0 +1,290 @@
+package logger
+
+// ===================================================
+// Imports Area
+// ===================================================
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ===================================================
+// Definitions Area
+// ===================================================
+
+// LevelHandler lets operators read and change log levels at runtime:
+//
+//	GET  /loglevel                    -> {"logger":"","level":"INFO"}
+//	PUT  /loglevel {"level":"debug","revert_after":"15m"}
+//	PUT  /loglevel?logger=db&level=debug&revert_after=15m
+//	GET  /loglevel?logger=*           -> every logger, default one first
+//
+// The logger query parameter picks a named logger or a prefix of one (setting
+// "db" also covers "db.pool"), empty means the default one.
+type LevelHandler struct {
+	// Applied to PUTs that don't ask for a revert_after of their own,
+	// so nobody can leave DEBUG on and go home. Zero means never revert.
+	RevertAfter time.Duration
+}
+
+// LevelState is what LevelHandler reports back
+type LevelState struct {
+	Logger string `json:"logger"`
+	Level  string `json:"level"`
+	// Set when a named logger has no level of its own and follows its parent
+	Inherited bool       `json:"inherited,omitempty"`
+	RevertAt  *time.Time `json:"revert_at,omitempty"`
+}
+
+// levelRequest is the PUT body, every field optional
+type levelRequest struct {
+	Level       string `json:"level"`
+	RevertAfter string `json:"revert_after"`
+}
+
+// levelRevert is the pending auto-revert of a logger's level, shared with
+// its children just like the level itself
+type levelRevert struct {
+	mu      sync.Mutex
+	timer   *time.Timer
+	restore func()
+	at      time.Time
+}
+
+// ===================================================
+// Public Functions Area
+// ===================================================
+
+// ParseLevel turns "debug", "WARN" and friends into a LogLevel
+func ParseLevel(s string) (LogLevel, error) {
+	switch strings.ToUpper(strings.TrimSpace(s)) {
+	case "TRACE":
+		return LevelTrace, nil
+	case "DEBUG":
+		return LevelDebug, nil
+	case "INFO":
+		return LevelInfo, nil
+	case "WARN", "WARNING":
+		return LevelWarning, nil
+	case "ERROR":
+		return LevelError, nil
+	case "FATAL":
+		return LevelFatal, nil
+	case "AUDIT":
+		return LevelAudit, nil
+	default:
+		return LevelInfo, fmt.Errorf("unknown log level %q", s)
+	}
+}
+
+// SetLogLevelFor changes the default logger's level for a while, see SetLevelFor
+func SetLogLevelFor(level LogLevel, d time.Duration) {
+	defaultLogger.SetLevelFor(level, d)
+}
+
+// SetLevelFor changes the level and puts it back after d. Calling it again
+// before then extends the deadline but still reverts to the original level;
+// SetLevel cancels the revert altogether.
+func (l *Logger) SetLevelFor(level LogLevel, d time.Duration) {
+	r := l.revert
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.timer == nil {
+		r.restore = l.levelRestorer()
+	} else {
+		r.timer.Stop()
+	}
+	l.storeLevel(level)
+
+	if d <= 0 {
+		r.timer = nil
+		r.at = time.Time{}
+		return
+	}
+
+	var timer *time.Timer
+	timer = time.AfterFunc(d, func() {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		// Superseded by a newer SetLevel or SetLevelFor
+		if r.timer != timer {
+			return
+		}
+		r.restore()
+		r.timer = nil
+		r.at = time.Time{}
+	})
+	r.timer = timer
+	r.at = time.Now().Add(d)
+}
+
+// RevertAt reports when a SetLevelFor will put the level back, if one is pending
+func (l *Logger) RevertAt() (time.Time, bool) {
+	l.revert.mu.Lock()
+	defer l.revert.mu.Unlock()
+	return l.revert.at, l.revert.timer != nil
+}
+
+// ToggleLevelOnSignal flips the default logger to level and back every time
+// one of sigs arrives, see Logger.ToggleLevelOnSignal
+func ToggleLevelOnSignal(level LogLevel, revertAfter time.Duration, sigs ...os.Signal) (stop func()) {
+	return defaultLogger.ToggleLevelOnSignal(level, revertAfter, sigs...)
+}
+
+// ToggleLevelOnSignal flips the logger to level when one of sigs arrives
+// (SIGUSR1 is the usual suspect) and back to where it was on the next one.
+// A positive revertAfter flips it back on its own if nobody sends the second
+// signal. Call stop to stop listening.
+func (l *Logger) ToggleLevelOnSignal(level LogLevel, revertAfter time.Duration, sigs ...os.Signal) (stop func()) {
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, sigs...)
+	done := make(chan struct{})
+
+	go func() {
+		previous := l.Level()
+		for {
+			select {
+			case <-sigChan:
+				if current := l.Level(); current != level {
+					previous = current
+					l.SetLevelFor(level, revertAfter)
+				} else {
+					l.SetLevel(previous)
+				}
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			signal.Stop(sigChan)
+			close(done)
+		})
+	}
+}
+
+// NewLevelHandler creates a LevelHandler whose changes revert after revertAfter,
+// zero means they stick
+func NewLevelHandler(revertAfter time.Duration) *LevelHandler {
+	return &LevelHandler{RevertAfter: revertAfter}
+}
+
+func (h *LevelHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	name := r.URL.Query().Get("logger")
+	if name == "*" && (r.Method == http.MethodGet || r.Method == http.MethodHead) {
+		states := []LevelState{levelState("", defaultLogger)}
+		for _, n := range LoggerNames() {
+			states = append(states, levelState(n, Named(n)))
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(states)
+		return
+	}
+	target, ok := lookupLevelTarget(name)
+	if !ok {
+		http.Error(w, fmt.Sprintf("unknown logger %q", name), http.StatusNotFound)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet, http.MethodHead:
+	case http.MethodPut:
+		if err := h.apply(target, r); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	default:
+		w.Header().Set("Allow", "GET, HEAD, PUT")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(levelState(name, target))
+}
+
+// ===================================================
+// Private Functions Area
+// ===================================================
+
+// lookupLevelTarget finds the logger the level endpoints talk about
+func lookupLevelTarget(name string) (*Logger, bool) {
+	name = strings.Trim(name, ".")
+	if name == "" {
+		return defaultLogger, true
+	}
+	if !registry.known(name) {
+		return nil, false
+	}
+	return Named(name), true
+}
+
+// apply reads a PUT from the body (JSON) or, failing that, the query string
+func (h *LevelHandler) apply(target *Logger, r *http.Request) error {
+	var req levelRequest
+	body, err := io.ReadAll(io.LimitReader(r.Body, 1<<16))
+	if err != nil {
+		return err
+	}
+	if len(strings.TrimSpace(string(body))) > 0 {
+		if err := json.Unmarshal(body, &req); err != nil {
+			return fmt.Errorf("invalid body: %w", err)
+		}
+	} else {
+		req.Level = r.URL.Query().Get("level")
+		req.RevertAfter = r.URL.Query().Get("revert_after")
+	}
+
+	level, err := ParseLevel(req.Level)
+	if err != nil {
+		return err
+	}
+	revertAfter := h.RevertAfter
+	if req.RevertAfter != "" {
+		if revertAfter, err = time.ParseDuration(req.RevertAfter); err != nil {
+			return fmt.Errorf("invalid revert_after: %w", err)
+		}
+	}
+
+	if revertAfter > 0 {
+		target.SetLevelFor(level, revertAfter)
+	} else {
+		target.SetLevel(level)
+	}
+	return nil
+}
+
+func levelState(name string, l *Logger) LevelState {
+	state := LevelState{Logger: name, Level: l.Level().String()}
+	if l.name != "" {
+		_, explicit := registry.explicit(l.name)
+		state.Inherited = !explicit
+	}
+	if at, ok := l.RevertAt(); ok {
+		state.RevertAt = &at
+	}
+	return state
+}
+
+// cancel drops any pending SetLevelFor, must not be called with r.mu held
+func (r *levelRevert) cancel() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.timer != nil {
+		r.timer.Stop()
+		r.timer = nil
+		r.at = time.Time{}
+	}
+}
@@ -0,0 +1,128 @@
+package logger_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/theHamdiz/it/logger"
+)
+
+func TestParseLevel(t *testing.T) {
+	for in, want := range map[string]logger.LogLevel{
+		"trace":   logger.LevelTrace,
+		"DEBUG":   logger.LevelDebug,
+		" warn ":  logger.LevelWarning,
+		"Warning": logger.LevelWarning,
+		"audit":   logger.LevelAudit,
+	} {
+		if got, err := logger.ParseLevel(in); err != nil || got != want {
+			t.Errorf("ParseLevel(%q) = %v, %v; want %v", in, got, err, want)
+		}
+	}
+	if _, err := logger.ParseLevel("loud"); err == nil {
+		t.Error("Expected an error for an unknown level")
+	}
+}
+
+func TestSetLevelFor_Reverts(t *testing.T) {
+	logger_, _ := newTestLogger()
+	logger_.SetLevel(logger.LevelWarning)
+
+	logger_.SetLevelFor(logger.LevelDebug, 20*time.Millisecond)
+	// A second bump must still revert to WARNING, not to DEBUG
+	logger_.SetLevelFor(logger.LevelTrace, 20*time.Millisecond)
+	if logger_.Level() != logger.LevelTrace {
+		t.Fatalf("Expected TRACE right away, got %v", logger_.Level())
+	}
+	if _, pending := logger_.RevertAt(); !pending {
+		t.Error("Expected a pending revert")
+	}
+
+	waitFor(t, func() bool { return logger_.Level() == logger.LevelWarning })
+	if _, pending := logger_.RevertAt(); pending {
+		t.Error("Expected no pending revert after it fired")
+	}
+}
+
+func TestSetLevel_CancelsRevert(t *testing.T) {
+	logger_, _ := newTestLogger()
+	logger_.SetLevelFor(logger.LevelDebug, 10*time.Millisecond)
+	logger_.With(map[string]any{"child": true}).SetLevel(logger.LevelError)
+
+	time.Sleep(30 * time.Millisecond)
+	if logger_.Level() != logger.LevelError {
+		t.Errorf("Expected SetLevel to win over the revert, got %v", logger_.Level())
+	}
+}
+
+func TestLevelHandler(t *testing.T) {
+	defer logger.SetLogLevel(logger.DefaultLogger().Level())
+	logger.SetLogLevel(logger.LevelInfo)
+
+	srv := httptest.NewServer(logger.NewLevelHandler(time.Hour))
+	defer srv.Close()
+
+	state := func(resp *http.Response) logger.LevelState {
+		t.Helper()
+		defer resp.Body.Close()
+		var s logger.LevelState
+		if err := json.NewDecoder(resp.Body).Decode(&s); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+		return s
+	}
+
+	resp, err := http.Get(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := state(resp); s.Level != "INFO" || s.RevertAt != nil {
+		t.Errorf("Expected INFO with no revert, got %+v", s)
+	}
+
+	req, _ := http.NewRequest(http.MethodPut, srv.URL, strings.NewReader(`{"level":"debug"}`))
+	resp, err = http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := state(resp); s.Level != "DEBUG" || s.RevertAt == nil {
+		t.Errorf("Expected DEBUG with the default revert, got %+v", s)
+	}
+
+	req, _ = http.NewRequest(http.MethodPut, srv.URL+"?level=error&revert_after=0s", nil)
+	resp, err = http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := state(resp); s.Level != "ERROR" || s.RevertAt != nil {
+		t.Errorf("Expected a sticky ERROR, got %+v", s)
+	}
+	if logger.DefaultLogger().Level() != logger.LevelError {
+		t.Errorf("Expected the default logger to follow, got %v", logger.DefaultLogger().Level())
+	}
+}
+
+func TestLevelHandler_Errors(t *testing.T) {
+	h := logger.NewLevelHandler(0)
+
+	for _, tc := range []struct {
+		method, target, body string
+		status               int
+	}{
+		{http.MethodPut, "/", `{"level":"loud"}`, http.StatusBadRequest},
+		{http.MethodPut, "/", `{"level":"info","revert_after":"soon"}`, http.StatusBadRequest},
+		{http.MethodPut, "/", `not json`, http.StatusBadRequest},
+		{http.MethodDelete, "/", "", http.StatusMethodNotAllowed},
+		{http.MethodGet, "/?logger=nope", "", http.StatusNotFound},
+	} {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(tc.method, tc.target, strings.NewReader(tc.body)))
+		if rec.Code != tc.status {
+			t.Errorf("%s %s %q: expected %d, got %d", tc.method, tc.target, tc.body, tc.status, rec.Code)
+		}
+	}
+}
@@ -0,0 +1,26 @@
+//go:build unix
+
+package logger_test
+
+import (
+	"os"
+	"syscall"
+	"testing"
+
+	"github.com/theHamdiz/it/logger"
+)
+
+func TestToggleLevelOnSignal(t *testing.T) {
+	logger_, _ := newTestLogger()
+	logger_.SetLevel(logger.LevelInfo)
+	stop := logger_.ToggleLevelOnSignal(logger.LevelDebug, 0, syscall.SIGUSR1)
+	defer stop()
+
+	if err := syscall.Kill(os.Getpid(), syscall.SIGUSR1); err != nil {
+		t.Skipf("cannot signal ourselves: %v", err)
+	}
+	waitFor(t, func() bool { return logger_.Level() == logger.LevelDebug })
+
+	_ = syscall.Kill(os.Getpid(), syscall.SIGUSR1)
+	waitFor(t, func() bool { return logger_.Level() == logger.LevelInfo })
+}
@@ -6,17 +6,14 @@ package logger
 
 import (
 	"bytes"
-	"encoding/json"
+	"context"
 	"fmt"
 	"io"
 	"os"
-	"path/filepath"
 	"runtime"
 	"sync"
 	"sync/atomic"
 	"time"
-
-	"github.com/fatih/color"
 )
 
 // ===================================================
@@ -46,18 +43,19 @@ type ILogger interface {
 
 // Logger handles all logging operations
 type Logger struct {
-	level atomic.Int32
-	// holds LogWriter
-	output atomic.Value
+	// level, output and onceMessages are pointers so child loggers created
+	// by With share them with their parent
+	level *atomic.Int32
+	// holds pipeline
+	output *atomic.Value
 	// maps message to sync.Once
-	onceMessages sync.Map
-	colors       struct {
-		magenta *color.Color
-		blue    *color.Color
-		red     *color.Color
-		cyan    *color.Color
-		yellow  *color.Color
-	}
+	onceMessages *sync.Map
+	// pending SetLevelFor, travels with level
+	revert *levelRevert
+	// stamped on every entry, see With
+	fields map[string]any
+	// set for loggers from Named, whose level lives in the registry
+	name string
 }
 
 func DefaultLogger() *Logger {
@@ -67,7 +65,16 @@ func DefaultLogger() *Logger {
 func NewLoggerWithLevelAndOutput(level LogLevel, w io.Writer) *Logger {
 	l := newDefaultLogger()
 	l.level.Store(int32(level))
-	l.output.Store(LogWriter{w})
+	l.SetOutput(w)
+	return l
+}
+
+// NewLoggerWithHandlers creates a logger that fans every entry out to the
+// given handlers, each of which still gets to apply its own threshold
+func NewLoggerWithHandlers(level LogLevel, handlers ...Handler) *Logger {
+	l := newDefaultLogger()
+	l.level.Store(int32(level))
+	l.SetHandlers(handlers...)
 	return l
 }
 
@@ -77,6 +84,9 @@ type StructuredLogEntry struct {
 	Message   string         `json:"message"`
 	Data      map[string]any `json:"data,omitempty"`
 	Caller    string         `json:"caller,omitempty"`
+	Error     *ErrorInfo     `json:"error,omitempty"`
+	TraceID   string         `json:"trace_id,omitempty"`
+	SpanID    string         `json:"span_id,omitempty"`
 }
 
 // ===================================================
@@ -93,8 +103,14 @@ const (
 	LevelAudit
 )
 
+// How long Fatal waits for buffered handlers before pulling the plug
+const fatalFlushTimeout = 5 * time.Second
+
 // Global logger instance
 var defaultLogger = newDefaultLogger()
+
+// Serializes pipeline changes; they're rare enough for every logger to share one
+var pipelineMu sync.Mutex
 var bufferPool = sync.Pool{
 	New: func() interface{} {
 		return &bytes.Buffer{}
@@ -114,11 +130,23 @@ var structuredLogPool = sync.Pool{
 // ===================================================
 
 func SetLogLevel(level LogLevel) {
-	defaultLogger.level.Store(int32(level))
+	defaultLogger.SetLevel(level)
 }
 
+// SetLogOutput is the single-handler shortcut: it replaces every handler of
+// the default logger with one colored text handler writing to w
 func SetLogOutput(w io.Writer) {
-	defaultLogger.output.Store(LogWriter{w})
+	defaultLogger.SetOutput(w)
+}
+
+// SetHandlers replaces the handlers of the default logger
+func SetHandlers(handlers ...Handler) {
+	defaultLogger.SetHandlers(handlers...)
+}
+
+// AddHandler adds one more destination to the default logger
+func AddHandler(h Handler) {
+	defaultLogger.AddHandler(h)
 }
 
 func (l LogLevel) String() string {
@@ -146,6 +174,52 @@ func (lw LogWriter) Write(p []byte) (n int, err error) {
 	return lw.w.Write(p)
 }
 
+// SetLevel changes the threshold of this logger and of every child sharing it,
+// cancelling any pending SetLevelFor
+func (l *Logger) SetLevel(level LogLevel) {
+	l.revert.cancel()
+	l.storeLevel(level)
+}
+
+// Level returns the threshold, for named loggers without a level of their
+// own that's the default logger's
+func (l *Logger) Level() LogLevel {
+	if level := l.level.Load(); level != levelInherit {
+		return LogLevel(level)
+	}
+	return defaultLogger.Level()
+}
+
+// SetOutput replaces every handler with one colored text handler writing to w
+func (l *Logger) SetOutput(w io.Writer) {
+	l.updatePipeline(func(p *pipeline) {
+		p.output = NewWriterHandler(w, NewFormatter(p.format), LevelTrace)
+		p.handlers = []Handler{p.output}
+	})
+}
+
+// SetHandlers replaces all handlers at once
+func (l *Logger) SetHandlers(handlers ...Handler) {
+	l.updatePipeline(func(p *pipeline) {
+		p.handlers = append([]Handler(nil), handlers...)
+		p.output = nil
+	})
+}
+
+// AddHandler appends a handler to the ones already installed
+func (l *Logger) AddHandler(h Handler) {
+	l.updatePipeline(func(p *pipeline) {
+		handlers := make([]Handler, 0, len(p.handlers)+1)
+		handlers = append(handlers, p.handlers...)
+		p.handlers = append(handlers, h)
+	})
+}
+
+// Handlers returns a copy of the installed handlers
+func (l *Logger) Handlers() []Handler {
+	return append([]Handler(nil), l.pipeline().handlers...)
+}
+
 func (l *Logger) Trace(msg string) {
 	l.log(LevelTrace, msg)
 }
@@ -168,6 +242,7 @@ func (l *Logger) Error(msg string) {
 
 func (l *Logger) Fatal(msg string) {
 	l.log(LevelFatal, msg)
+	l.flushBeforeExit()
 	// Because sometimes you just need to rage-quit
 	os.Exit(1)
 }
@@ -195,61 +270,26 @@ func (l *Logger) Errorf(format string, args ...interface{}) {
 
 func (l *Logger) Fatalf(format string, args ...interface{}) {
 	l.logf(LevelFatal, format, args...)
+	l.flushBeforeExit()
 	// Still rage-quitting, just with more style
 	os.Exit(1)
 }
 
 func (l *Logger) StructuredLog(level LogLevel, msg string, data map[string]any) {
-	if !l.shouldLog(level) {
-		return
-	}
-
-	entry := structuredLogPool.Get().(*StructuredLogEntry)
-	defer structuredLogPool.Put(entry)
-
-	// Reset the entry
-	entry.Timestamp = time.Now()
-	entry.Level = level.String()
-	entry.Message = msg
-	for k, v := range data {
-		entry.Data[k] = v
-	}
-
-	// Add caller info in debug level
-	if level <= LevelDebug {
-		if _, file, line, ok := runtime.Caller(2); ok {
-			entry.Caller = fmt.Sprintf("%s:%d", filepath.Base(file), line)
-		}
-	}
-
-	buf := bufferPool.Get().(*bytes.Buffer)
-	buf.Reset()
-	defer bufferPool.Put(buf)
-
-	enc := json.NewEncoder(buf)
-	enc.SetEscapeHTML(false)
-	if err := enc.Encode(entry); err != nil {
-		l.Error(fmt.Sprintf("Failed to encode structured log: %v", err))
-		return
-	}
-
-	writer := l.getWriter()
-	if _, err := writer.Write(buf.Bytes()); err != nil {
-		l.Error(fmt.Sprintf("Failed to write structured log: %v", err))
-	}
+	l.structured(level, msg, data)
 }
 
 // StructuredInfo is a Convenience methods for structured logging
 func (l *Logger) StructuredInfo(msg string, data map[string]any) {
-	l.StructuredLog(LevelInfo, msg, data)
+	l.structured(LevelInfo, msg, data)
 }
 
 func (l *Logger) StructuredDebug(msg string, data map[string]any) {
-	l.StructuredLog(LevelDebug, msg, data)
+	l.structured(LevelDebug, msg, data)
 }
 
 func (l *Logger) StructuredError(msg string, data map[string]any) {
-	l.StructuredLog(LevelError, msg, data)
+	l.structured(LevelError, msg, data)
 }
 
 // LogOnce Add a message to the log only once.
@@ -273,44 +313,15 @@ func (l *Logger) LogOncef(format string, args ...interface{}) {
 // Private Functions Area
 // ===================================================
 
-func (l *Logger) writeColored(level LogLevel, msg string) {
-	writer := l.getWriter()
-	var err error
-
-	switch level {
-	case LevelTrace:
-		_, err = l.colors.magenta.Fprint(writer, msg)
-	case LevelDebug:
-		_, err = l.colors.blue.Fprint(writer, msg)
-	case LevelInfo:
-		_, err = l.colors.cyan.Fprint(writer, msg)
-	case LevelWarning:
-		_, err = l.colors.yellow.Fprint(writer, msg)
-	case LevelError, LevelFatal:
-		_, err = l.colors.red.Fprint(writer, msg)
-	default:
-		_, err = fmt.Fprint(writer, msg)
-	}
-
-	if err != nil {
-		// If we can't log, we're probably in trouble, but let's try one last time
-		_, err := fmt.Fprintf(os.Stderr, "Logging error: %v\n", err)
-		if err != nil {
-			return
-		}
-	}
-}
-
 func newDefaultLogger() *Logger {
-	l := &Logger{}
+	l := &Logger{
+		level:        &atomic.Int32{},
+		output:       &atomic.Value{},
+		onceMessages: &sync.Map{},
+		revert:       &levelRevert{},
+	}
 	l.level.Store(int32(LevelInfo))
-	l.output.Store(LogWriter{os.Stdout})
-	l.onceMessages = sync.Map{}
-	l.colors.magenta = color.New(color.FgMagenta)
-	l.colors.blue = color.New(color.FgBlue)
-	l.colors.red = color.New(color.FgRed)
-	l.colors.cyan = color.New(color.FgCyan)
-	l.colors.yellow = color.New(color.FgYellow)
+	l.SetOutput(os.Stdout)
 	return l
 }
 
@@ -318,28 +329,107 @@ func (l *Logger) log(level LogLevel, msg string) {
 	if !l.shouldLog(level) {
 		return
 	}
-
-	prefix := l.getLevelPrefix(level)
-	// Still keeping our emoji-based logging because we're not monsters
-	l.writeColored(level, fmt.Sprintf("%s %s\n", prefix, msg))
+	l.emit(level, msg, nil, false, 2)
 }
 
 func (l *Logger) logf(level LogLevel, format string, args ...interface{}) {
 	if !l.shouldLog(level) {
 		return
 	}
-	l.log(level, fmt.Sprintf(format, args...))
+	l.emit(level, fmt.Sprintf(format, args...), nil, false, 2)
+}
+
+func (l *Logger) structured(level LogLevel, msg string, data map[string]any) {
+	if !l.shouldLog(level) {
+		return
+	}
+	l.emit(level, msg, data, true, 2)
+}
+
+// emit builds the record and hands it to every interested handler.
+// skip is the number of logger frames sitting between emit and the user.
+func (l *Logger) emit(level LogLevel, msg string, data map[string]any, structured bool, skip int) {
+	l.dispatch(l.record(level, msg, data, structured, skip+1))
+}
+
+// record builds a record stamped with the call site, skip works like emit's
+func (l *Logger) record(level LogLevel, msg string, data map[string]any, structured bool, skip int) Record {
+	r := Record{
+		Time:       time.Now(),
+		Level:      level,
+		Message:    msg,
+		Data:       l.mergeFields(data),
+		Structured: structured,
+	}
+
+	var pcs [1]uintptr
+	// Skip runtime.Callers, record and the logger frames above it
+	if runtime.Callers(skip+2, pcs[:]) > 0 {
+		r.PC = pcs[0]
+	}
+	return r
+}
+
+// mergeFields copies data on top of the logger's own fields, because the
+// caller is free to reuse the map once we return. Call-site data wins.
+func (l *Logger) mergeFields(data map[string]any) map[string]any {
+	if len(data) == 0 && len(l.fields) == 0 {
+		return nil
+	}
+	merged := make(map[string]any, len(data)+len(l.fields))
+	for k, v := range l.fields {
+		merged[k] = v
+	}
+	for k, v := range data {
+		merged[k] = v
+	}
+	return merged
+}
+
+func (l *Logger) dispatch(r Record) {
+	p := l.pipeline()
+	if p.sampler != nil && !p.sampler.allow(r) {
+		return
+	}
+	if p.redactor != nil {
+		r.Data = p.redactor.Redact(r.Data)
+		if r.Error != nil {
+			r.Error = p.redactor.redactError(r.Error)
+		}
+	}
+	p.write(r)
+}
+
+// flushBeforeExit gives buffered handlers a moment to get the last words out
+func (l *Logger) flushBeforeExit() {
+	ctx, cancel := context.WithTimeout(context.Background(), fatalFlushTimeout)
+	defer cancel()
+	_ = l.Flush(ctx)
 }
 
 func (l *Logger) shouldLog(level LogLevel) bool {
-	return LogLevel(l.level.Load()) <= level
+	return l.Level() <= level
+}
+
+func (l *Logger) pipeline() pipeline {
+	p, _ := l.output.Load().(pipeline)
+	return p
 }
 
-func (l *Logger) getWriter() LogWriter {
-	return l.output.Load().(LogWriter)
+// updatePipeline applies update to a copy of the pipeline and stores it.
+// Load, change and store all happen under pipelineMu, so two setters racing
+// each other can't quietly undo one another (say, AddHandler dropping the
+// redactor SetRedactor just installed).
+func (l *Logger) updatePipeline(update func(p *pipeline)) pipeline {
+	pipelineMu.Lock()
+	defer pipelineMu.Unlock()
+	p := l.pipeline()
+	update(&p)
+	l.output.Store(p)
+	return p
 }
 
-func (l *Logger) getLevelPrefix(level LogLevel) string {
+func getLevelPrefix(level LogLevel) string {
 	switch level {
 	case LevelTrace:
 		return "[🛤️ TRACE]"
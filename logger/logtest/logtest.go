@@ -0,0 +1,232 @@
+// Package logtest - Because grepping log output for emoji is not a testing strategy
+package logtest
+
+// ===================================================
+// Imports Area
+// ===================================================
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/theHamdiz/it/logger"
+)
+
+// ===================================================
+// Definitions Area
+// ===================================================
+
+// Entry is one captured log entry, typed instead of rendered
+type Entry struct {
+	Time       time.Time
+	Level      logger.LogLevel
+	Message    string
+	Fields     map[string]any
+	Caller     string
+	Structured bool
+	Error      *logger.ErrorInfo
+	TraceID    string
+	SpanID     string
+}
+
+// Recorder is a logger.Handler that keeps every record in memory
+type Recorder struct {
+	mu      sync.Mutex
+	entries []Entry
+}
+
+// ===================================================
+// Public Functions Area
+// ===================================================
+
+// NewRecorder creates an empty recorder
+func NewRecorder() *Recorder {
+	return &Recorder{}
+}
+
+// New returns a fresh logger that lets everything through to a recorder
+func New() (*logger.Logger, *Recorder) {
+	r := NewRecorder()
+	return logger.NewLoggerWithHandlers(logger.LevelTrace, r), r
+}
+
+// Capture points the default logger, and so every named logger, at a
+// recorder for the rest of the test. The default logger's level and
+// handlers are put back when the test ends. Tests using Capture must not
+// run in parallel with each other.
+func Capture(t testing.TB) *Recorder {
+	t.Helper()
+	l := logger.DefaultLogger()
+	level, handlers := l.Level(), l.Handlers()
+	t.Cleanup(func() {
+		l.SetHandlers(handlers...)
+		l.SetLevel(level)
+	})
+
+	r := NewRecorder()
+	l.SetHandlers(r)
+	l.SetLevel(logger.LevelTrace)
+	return r
+}
+
+func (r *Recorder) Enabled(logger.LogLevel) bool {
+	return true
+}
+
+func (r *Recorder) Handle(rec logger.Record) error {
+	e := Entry{
+		Time:       rec.Time,
+		Level:      rec.Level,
+		Message:    rec.Message,
+		Caller:     rec.Caller(),
+		Structured: rec.Structured,
+		Error:      rec.Error,
+		TraceID:    rec.TraceID,
+		SpanID:     rec.SpanID,
+	}
+	if len(rec.Data) > 0 {
+		e.Fields = make(map[string]any, len(rec.Data))
+		for k, v := range rec.Data {
+			e.Fields[k] = v
+		}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.entries = append(r.entries, e)
+	return nil
+}
+
+// Entries returns everything captured so far, oldest first
+func (r *Recorder) Entries() []Entry {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]Entry(nil), r.entries...)
+}
+
+// Len returns how many entries were captured
+func (r *Recorder) Len() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.entries)
+}
+
+// Reset forgets everything captured so far
+func (r *Recorder) Reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.entries = nil
+}
+
+// Filter returns the entries keep says yes to
+func (r *Recorder) Filter(keep func(Entry) bool) []Entry {
+	var out []Entry
+	for _, e := range r.Entries() {
+		if keep(e) {
+			out = append(out, e)
+		}
+	}
+	return out
+}
+
+// Find returns the entries at level whose message contains msg and whose
+// fields include every one of fields. An empty msg matches any message.
+func (r *Recorder) Find(level logger.LogLevel, msg string, fields map[string]any) []Entry {
+	return r.Filter(func(e Entry) bool { return e.Matches(level, msg, fields) })
+}
+
+// Contains reports whether anything matches, see Find
+func (r *Recorder) Contains(level logger.LogLevel, msg string, fields map[string]any) bool {
+	return len(r.Find(level, msg, fields)) > 0
+}
+
+// AssertContains fails the test unless something matches, see Find
+func (r *Recorder) AssertContains(t testing.TB, level logger.LogLevel, msg string, fields map[string]any) {
+	t.Helper()
+	if !r.Contains(level, msg, fields) {
+		t.Errorf("Expected a %s entry containing %q with fields %v, got:\n%s", level, msg, fields, r.dump())
+	}
+}
+
+// AssertNotContains fails the test if anything matches, see Find
+func (r *Recorder) AssertNotContains(t testing.TB, level logger.LogLevel, msg string, fields map[string]any) {
+	t.Helper()
+	if found := r.Find(level, msg, fields); len(found) > 0 {
+		t.Errorf("Expected no %s entry containing %q with fields %v, got:\n%s", level, msg, fields, dump(found))
+	}
+}
+
+// AssertCount fails the test unless exactly n entries match, see Find
+func (r *Recorder) AssertCount(t testing.TB, n int, level logger.LogLevel, msg string, fields map[string]any) {
+	t.Helper()
+	if found := r.Find(level, msg, fields); len(found) != n {
+		t.Errorf("Expected %d %s entries containing %q with fields %v, got %d:\n%s", n, level, msg, fields, len(found), r.dump())
+	}
+}
+
+// AssertEmpty fails the test if anything was logged at all
+func (r *Recorder) AssertEmpty(t testing.TB) {
+	t.Helper()
+	if r.Len() > 0 {
+		t.Errorf("Expected no log entries, got:\n%s", r.dump())
+	}
+}
+
+// Matches reports whether e is at level, its message contains msg and its
+// fields include every one of fields
+func (e Entry) Matches(level logger.LogLevel, msg string, fields map[string]any) bool {
+	if e.Level != level || !strings.Contains(e.Message, msg) {
+		return false
+	}
+	for k, want := range fields {
+		got, ok := e.Fields[k]
+		if !ok || !reflect.DeepEqual(got, want) {
+			return false
+		}
+	}
+	return true
+}
+
+func (e Entry) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s %q", e.Level, e.Message)
+	keys := make([]string, 0, len(e.Fields))
+	for k := range e.Fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		fmt.Fprintf(&b, " %s=%v", k, e.Fields[k])
+	}
+	if e.Error != nil {
+		fmt.Fprintf(&b, " error=%q", e.Error.Message)
+	}
+	if e.Caller != "" {
+		fmt.Fprintf(&b, " (%s)", e.Caller)
+	}
+	return b.String()
+}
+
+// ===================================================
+// Private Functions Area
+// ===================================================
+
+func (r *Recorder) dump() string {
+	return dump(r.Entries())
+}
+
+func dump(entries []Entry) string {
+	if len(entries) == 0 {
+		return "  (nothing)"
+	}
+	lines := make([]string, len(entries))
+	for i, e := range entries {
+		lines[i] = "  " + e.String()
+	}
+	return strings.Join(lines, "\n")
+}
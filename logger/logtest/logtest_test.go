@@ -0,0 +1,96 @@
+package logtest_test
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/theHamdiz/it/logger"
+	"github.com/theHamdiz/it/logger/logtest"
+)
+
+// fakeT records failures instead of failing the real test
+type fakeT struct {
+	testing.TB
+	failures []string
+}
+
+func (f *fakeT) Helper() {}
+
+func (f *fakeT) Errorf(format string, args ...any) {
+	f.failures = append(f.failures, fmt.Sprintf(format, args...))
+}
+
+func TestRecorder_TypedEntries(t *testing.T) {
+	l, rec := logtest.New()
+
+	l.With(map[string]any{"request_id": "r-1"}).StructuredInfo("user created", map[string]any{"user": "bob"})
+	l.Warn("disk almost full")
+	l.ErrorErr(errors.New("boom"), "save failed", nil)
+
+	entries := rec.Entries()
+	if len(entries) != 3 {
+		t.Fatalf("Expected 3 entries, got %d", len(entries))
+	}
+	first := entries[0]
+	if first.Level != logger.LevelInfo || first.Message != "user created" || !first.Structured {
+		t.Errorf("Unexpected first entry %+v", first)
+	}
+	if first.Fields["user"] != "bob" || first.Fields["request_id"] != "r-1" {
+		t.Errorf("Expected call-site and logger fields, got %v", first.Fields)
+	}
+	if !strings.HasPrefix(first.Caller, "logtest_test.go:") {
+		t.Errorf("Expected the caller to be this file, got %q", first.Caller)
+	}
+	if entries[2].Error == nil || entries[2].Error.Message != "boom" {
+		t.Errorf("Expected the error to be captured, got %+v", entries[2].Error)
+	}
+}
+
+func TestRecorder_Assertions(t *testing.T) {
+	l, rec := logtest.New()
+	l.StructuredError("payment failed", map[string]any{"order": 42, "retry": true})
+	l.Info("retrying")
+	l.Info("retrying")
+
+	rec.AssertContains(t, logger.LevelError, "payment", map[string]any{"order": 42})
+	rec.AssertNotContains(t, logger.LevelError, "payment", map[string]any{"order": 43})
+	rec.AssertCount(t, 2, logger.LevelInfo, "retrying", nil)
+
+	ft := &fakeT{}
+	rec.AssertContains(ft, logger.LevelWarning, "payment", nil)
+	rec.AssertEmpty(ft)
+	rec.AssertCount(ft, 1, logger.LevelInfo, "", nil)
+	if len(ft.failures) != 3 {
+		t.Fatalf("Expected 3 failures, got %d: %v", len(ft.failures), ft.failures)
+	}
+	if !strings.Contains(ft.failures[0], `ERROR "payment failed" order=42 retry=true`) {
+		t.Errorf("Expected the failure to list what was logged, got %q", ft.failures[0])
+	}
+
+	rec.Reset()
+	rec.AssertEmpty(t)
+}
+
+func TestCapture_RestoresDefaultLogger(t *testing.T) {
+	before := logger.DefaultLogger().Level()
+	handlers := logger.DefaultLogger().Handlers()
+
+	t.Run("scoped", func(t *testing.T) {
+		rec := logtest.Capture(t)
+		logger.DefaultLogger().Trace("default logger")
+		logger.Named("logtest.component").Debug("named logger")
+
+		rec.AssertContains(t, logger.LevelTrace, "default logger", nil)
+		rec.AssertContains(t, logger.LevelDebug, "named logger", map[string]any{"logger": "logtest.component"})
+	})
+
+	if logger.DefaultLogger().Level() != before {
+		t.Errorf("Expected level %v back, got %v", before, logger.DefaultLogger().Level())
+	}
+	after := logger.DefaultLogger().Handlers()
+	if len(after) != len(handlers) || after[0] != handlers[0] {
+		t.Errorf("Expected the original handlers back, got %v", after)
+	}
+}
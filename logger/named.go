@@ -0,0 +1,224 @@
+package logger
+
+// ===================================================
+// Imports Area
+// ===================================================
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// ===================================================
+// Definitions Area
+// ===================================================
+
+// loggerRegistry keeps track of named loggers and the levels set on their
+// dotted prefixes. A logger takes the level of its longest prefix that has
+// one ("db.pool" looks at "db.pool", then "db"), and follows the default
+// logger when none do.
+type loggerRegistry struct {
+	mu      sync.Mutex
+	loggers map[string]*Logger
+	levels  map[string]LogLevel
+}
+
+// ===================================================
+// Declarations Area
+// ===================================================
+
+// levelInherit is stored in a named logger's level while it has no level of
+// its own, Level then asks the default logger
+const levelInherit = math.MinInt32
+
+var registry = &loggerRegistry{
+	loggers: make(map[string]*Logger),
+	levels:  make(map[string]LogLevel),
+}
+
+// ===================================================
+// Public Functions Area
+// ===================================================
+
+// Named returns the logger for a component, creating it on first use. Names
+// are dotted paths ("db.pool"), entries carry a logger=<name> field, and the
+// output is shared with the default logger; only the level is per component.
+// The empty name is the default logger.
+func Named(name string) *Logger {
+	name = strings.Trim(name, ".")
+	if name == "" {
+		return defaultLogger
+	}
+	return registry.get(name)
+}
+
+// Named returns the logger for a sub-component of this one
+func (l *Logger) Named(name string) *Logger {
+	if l.name == "" {
+		return Named(name)
+	}
+	return Named(l.name + "." + strings.Trim(name, "."))
+}
+
+// Name returns the name this logger was created with, empty for unnamed ones
+func (l *Logger) Name() string {
+	return l.name
+}
+
+// SetNamedLevel sets the level of prefix and of everything under it that
+// doesn't have a level of its own
+func SetNamedLevel(prefix string, level LogLevel) {
+	Named(prefix).SetLevel(level)
+}
+
+// ResetNamedLevel makes prefix inherit its level again
+func ResetNamedLevel(prefix string) {
+	prefix = strings.Trim(prefix, ".")
+	if prefix == "" {
+		return
+	}
+	if l, ok := registry.lookup(prefix); ok {
+		l.revert.cancel()
+	}
+	registry.clear(prefix)
+}
+
+// NamedLevels returns the levels explicitly set per prefix
+func NamedLevels() map[string]LogLevel {
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+	levels := make(map[string]LogLevel, len(registry.levels))
+	for k, v := range registry.levels {
+		levels[k] = v
+	}
+	return levels
+}
+
+// LoggerNames returns the names of every named logger created so far, sorted
+func LoggerNames() []string {
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+	names := make([]string, 0, len(registry.loggers))
+	for name := range registry.loggers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ===================================================
+// Private Functions Area
+// ===================================================
+
+func (r *loggerRegistry) get(name string) *Logger {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if l, ok := r.loggers[name]; ok {
+		return l
+	}
+	l := &Logger{
+		level:        &atomic.Int32{},
+		output:       defaultLogger.output,
+		onceMessages: &sync.Map{},
+		revert:       &levelRevert{},
+		fields:       map[string]any{"logger": name},
+		name:         name,
+	}
+	l.level.Store(r.effective(name))
+	r.loggers[name] = l
+	return l
+}
+
+func (r *loggerRegistry) lookup(name string) (*Logger, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	l, ok := r.loggers[name]
+	return l, ok
+}
+
+// known reports whether name is a logger, or a prefix of one
+func (r *loggerRegistry) known(name string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for n := range r.loggers {
+		if n == name || strings.HasPrefix(n, name+".") {
+			return true
+		}
+	}
+	return false
+}
+
+// explicit returns the level set on exactly name, if any
+func (r *loggerRegistry) explicit(name string) (LogLevel, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	level, ok := r.levels[name]
+	return level, ok
+}
+
+func (r *loggerRegistry) set(prefix string, level LogLevel) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.levels[prefix] = level
+	r.refresh(prefix)
+}
+
+func (r *loggerRegistry) clear(prefix string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.levels, prefix)
+	r.refresh(prefix)
+}
+
+// refresh recomputes the level of prefix and everything under it,
+// must be called with r.mu held
+func (r *loggerRegistry) refresh(prefix string) {
+	for name, l := range r.loggers {
+		if name == prefix || strings.HasPrefix(name, prefix+".") {
+			l.level.Store(r.effective(name))
+		}
+	}
+}
+
+// effective walks up the dotted path looking for a level,
+// must be called with r.mu held
+func (r *loggerRegistry) effective(name string) int32 {
+	for {
+		if level, ok := r.levels[name]; ok {
+			return int32(level)
+		}
+		i := strings.LastIndexByte(name, '.')
+		if i < 0 {
+			return levelInherit
+		}
+		name = name[:i]
+	}
+}
+
+// storeLevel sets the level, through the registry for named loggers so
+// their descendants follow
+func (l *Logger) storeLevel(level LogLevel) {
+	if l.name != "" {
+		registry.set(l.name, level)
+		return
+	}
+	l.level.Store(int32(level))
+}
+
+// levelRestorer captures the current level so SetLevelFor can put it back,
+// including "no level of my own" for named loggers
+func (l *Logger) levelRestorer() func() {
+	if l.name == "" {
+		previous := LogLevel(l.level.Load())
+		return func() { l.level.Store(int32(previous)) }
+	}
+	name := l.name
+	if previous, ok := registry.explicit(name); ok {
+		return func() { registry.set(name, previous) }
+	}
+	return func() { registry.clear(name) }
+}
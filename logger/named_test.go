@@ -0,0 +1,157 @@
+package logger_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/theHamdiz/it/logger"
+)
+
+// captureDefault points the default logger, and so every named logger, at a buffer
+func captureDefault(t *testing.T) *testWriter {
+	t.Helper()
+	buf := &testWriter{}
+	level := logger.DefaultLogger().Level()
+	logger.SetLogOutput(buf)
+	t.Cleanup(func() {
+		logger.SetLogOutput(os.Stdout)
+		logger.SetLogLevel(level)
+	})
+	return buf
+}
+
+func TestNamed_SharesOutputAndTagsEntries(t *testing.T) {
+	buf := captureDefault(t)
+	logger.SetLogLevel(logger.LevelInfo)
+
+	l := logger.Named("named.tags")
+	if logger.Named(".named.tags.") != l {
+		t.Error("Expected the same logger for the same name")
+	}
+	if logger.Named("") != logger.DefaultLogger() {
+		t.Error("Expected the empty name to be the default logger")
+	}
+	if l.Name() != "named.tags" || l.Named("child").Name() != "named.tags.child" {
+		t.Errorf("Unexpected names %q, %q", l.Name(), l.Named("child").Name())
+	}
+
+	l.Info("hello")
+	if !strings.Contains(buf.String(), "hello logger=named.tags") {
+		t.Errorf("Expected the entry tagged with the logger name, got %q", buf.String())
+	}
+}
+
+func TestNamed_InheritsFromDefault(t *testing.T) {
+	captureDefault(t)
+	l := logger.Named("named.inherit.pool")
+
+	logger.SetLogLevel(logger.LevelError)
+	if l.Level() != logger.LevelError {
+		t.Errorf("Expected the default level, got %v", l.Level())
+	}
+	logger.SetLogLevel(logger.LevelDebug)
+	if l.Level() != logger.LevelDebug {
+		t.Errorf("Expected to follow the default level, got %v", l.Level())
+	}
+}
+
+func TestNamed_PrefixLevels(t *testing.T) {
+	buf := captureDefault(t)
+	logger.SetLogLevel(logger.LevelInfo)
+	t.Cleanup(func() {
+		logger.ResetNamedLevel("named.db")
+		logger.ResetNamedLevel("named.db.pool")
+	})
+
+	pool := logger.Named("named.db.pool")
+	conn := logger.Named("named.db.conn")
+	other := logger.Named("named.http")
+
+	logger.SetNamedLevel("named.db", logger.LevelDebug)
+	if pool.Level() != logger.LevelDebug || conn.Level() != logger.LevelDebug {
+		t.Errorf("Expected the prefix level to be inherited, got %v and %v", pool.Level(), conn.Level())
+	}
+	if other.Level() != logger.LevelInfo {
+		t.Errorf("Expected siblings of the prefix untouched, got %v", other.Level())
+	}
+
+	// The longest prefix wins
+	pool.SetLevel(logger.LevelError)
+	if pool.Level() != logger.LevelError || conn.Level() != logger.LevelDebug {
+		t.Errorf("Expected pool ERROR and conn DEBUG, got %v and %v", pool.Level(), conn.Level())
+	}
+	if got := logger.NamedLevels(); got["named.db"] != logger.LevelDebug || got["named.db.pool"] != logger.LevelError {
+		t.Errorf("Unexpected explicit levels %v", got)
+	}
+
+	conn.Debug("conn chatter")
+	other.Debug("http chatter")
+	if !strings.Contains(buf.String(), "conn chatter") || strings.Contains(buf.String(), "http chatter") {
+		t.Errorf("Expected only db DEBUG lines, got %q", buf.String())
+	}
+
+	logger.ResetNamedLevel("named.db.pool")
+	if pool.Level() != logger.LevelDebug {
+		t.Errorf("Expected pool to inherit again, got %v", pool.Level())
+	}
+}
+
+func TestNamed_SetLevelForRestoresInheritance(t *testing.T) {
+	captureDefault(t)
+	logger.SetLogLevel(logger.LevelWarning)
+	l := logger.Named("named.revert")
+
+	l.SetLevelFor(logger.LevelTrace, 10*time.Millisecond)
+	if l.Level() != logger.LevelTrace {
+		t.Fatalf("Expected TRACE, got %v", l.Level())
+	}
+	waitFor(t, func() bool { return l.Level() == logger.LevelWarning })
+
+	if _, ok := logger.NamedLevels()["named.revert"]; ok {
+		t.Error("Expected the revert to drop the explicit level, not pin WARNING")
+	}
+}
+
+func TestLevelHandler_NamedLoggers(t *testing.T) {
+	captureDefault(t)
+	logger.SetLogLevel(logger.LevelInfo)
+	t.Cleanup(func() { logger.ResetNamedLevel("named.api") })
+	users := logger.Named("named.api.users")
+
+	h := logger.NewLevelHandler(0)
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodPut, "/?logger=named.api", strings.NewReader(`{"level":"trace"}`)))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected 200 for a known prefix, got %d: %s", rec.Code, rec.Body)
+	}
+	if users.Level() != logger.LevelTrace {
+		t.Errorf("Expected the prefix level to reach named.api.users, got %v", users.Level())
+	}
+
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/?logger=*", nil))
+	var states []logger.LevelState
+	if err := json.NewDecoder(rec.Body).Decode(&states); err != nil {
+		t.Fatalf("Failed to decode listing: %v", err)
+	}
+	if states[0].Logger != "" {
+		t.Errorf("Expected the default logger first, got %+v", states[0])
+	}
+	found := false
+	for _, s := range states {
+		if s.Logger == "named.api.users" {
+			found = true
+			if s.Level != "TRACE" || !s.Inherited {
+				t.Errorf("Expected an inherited TRACE, got %+v", s)
+			}
+		}
+	}
+	if !found {
+		t.Errorf("Expected named.api.users in the listing, got %+v", states)
+	}
+}
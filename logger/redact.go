@@ -0,0 +1,352 @@
+package logger
+
+// ===================================================
+// Imports Area
+// ===================================================
+
+import (
+	"encoding"
+	"encoding/json"
+	"path"
+	"reflect"
+	"regexp"
+	"strings"
+)
+
+// ===================================================
+// Definitions Area
+// ===================================================
+
+// Redactor scrubs sensitive values out of structured data before it reaches
+// any handler. Keys are matched case-insensitively, by exact name or glob;
+// string values anywhere in the tree are matched against regexes.
+type Redactor struct {
+	keys     map[string]struct{}
+	patterns []string
+	values   []*regexp.Regexp
+	mask     string
+}
+
+// seenKey identifies a pointer, map or slice on the path being walked
+type seenKey struct {
+	ptr uintptr
+	typ reflect.Type
+	len int
+}
+
+// RedactorOption - Functional options, because the alternative is a struct
+// with twelve fields nobody remembers
+type RedactorOption func(*Redactor)
+
+// ===================================================
+// Declarations Area
+// ===================================================
+
+// DefaultMask is what sensitive values get replaced with
+const DefaultMask = "[REDACTED]"
+
+// cycleMarker stands in for a value that refers back to one of its parents,
+// so a self-referential struct costs a placeholder instead of the stack
+const cycleMarker = "[CYCLE]"
+
+// Value patterns for the usual suspects
+var (
+	PatternJWT        = regexp.MustCompile(`eyJ[A-Za-z0-9_-]+\.[A-Za-z0-9_-]+\.[A-Za-z0-9_-]*`)
+	PatternCardNumber = regexp.MustCompile(`\b(?:\d[ -]?){12,18}\d\b`)
+	PatternEmail      = regexp.MustCompile(`[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}`)
+)
+
+// Key names that have leaked into our logs one time too many
+var defaultRedactedKeys = []string{
+	"password", "passwd", "pwd", "secret", "token", "api_key", "apikey",
+	"authorization", "cookie", "set-cookie", "private_key", "access_token",
+	"refresh_token", "client_secret", "card_number", "cvv", "ssn",
+}
+
+var defaultRedactedKeyPatterns = []string{"*password*", "*secret*", "*token*"}
+
+var (
+	jsonMarshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+// ===================================================
+// Public Functions Area
+// ===================================================
+
+// NewRedactor creates a redactor that redacts nothing until told otherwise
+func NewRedactor(opts ...RedactorOption) *Redactor {
+	r := &Redactor{
+		keys: make(map[string]struct{}),
+		mask: DefaultMask,
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
+
+// DefaultRedactor knows the usual secret key names plus JWTs, card numbers
+// and email addresses
+func DefaultRedactor() *Redactor {
+	return NewRedactor(
+		RedactKeys(defaultRedactedKeys...),
+		RedactKeyPatterns(defaultRedactedKeyPatterns...),
+		RedactValues(PatternJWT, PatternCardNumber, PatternEmail),
+	)
+}
+
+// RedactKeys masks the whole value of any key with one of these names
+func RedactKeys(keys ...string) RedactorOption {
+	return func(r *Redactor) {
+		for _, k := range keys {
+			r.keys[strings.ToLower(k)] = struct{}{}
+		}
+	}
+}
+
+// RedactKeyPatterns masks the whole value of any key matching one of these
+// globs, using path.Match syntax
+func RedactKeyPatterns(globs ...string) RedactorOption {
+	return func(r *Redactor) {
+		for _, g := range globs {
+			r.patterns = append(r.patterns, strings.ToLower(g))
+		}
+	}
+}
+
+// RedactValues masks the parts of any string value matching these patterns
+func RedactValues(patterns ...*regexp.Regexp) RedactorOption {
+	return func(r *Redactor) {
+		r.values = append(r.values, patterns...)
+	}
+}
+
+// WithMask changes what redacted values are replaced with
+func WithMask(mask string) RedactorOption {
+	return func(r *Redactor) {
+		r.mask = mask
+	}
+}
+
+// SetRedactor installs a redactor on the default logger, nil removes it
+func SetRedactor(r *Redactor) {
+	defaultLogger.SetRedactor(r)
+}
+
+// SetRedactor installs a redactor on this logger's whole tree, nil removes
+// it. The parent this logger came from and every With child share it, just
+// as they share handlers.
+func (l *Logger) SetRedactor(r *Redactor) {
+	l.updatePipeline(func(p *pipeline) {
+		p.redactor = r
+	})
+}
+
+// Redactor returns the installed redactor, or nil
+func (l *Logger) Redactor() *Redactor {
+	return l.pipeline().redactor
+}
+
+// Redact returns a scrubbed copy of data. The original is never modified,
+// and untouched values (structs included) are passed through as they are.
+func (r *Redactor) Redact(data map[string]any) map[string]any {
+	if r == nil || data == nil {
+		return data
+	}
+	out := make(map[string]any, len(data))
+	seen := make(map[seenKey]struct{})
+	for k, v := range data {
+		if r.matchesKey(k) {
+			out[k] = r.mask
+			continue
+		}
+		out[k], _ = r.redact(reflect.ValueOf(v), seen)
+	}
+	return out
+}
+
+// RedactString masks every value pattern found in s
+func (r *Redactor) RedactString(s string) string {
+	if r == nil {
+		return s
+	}
+	for _, re := range r.values {
+		s = re.ReplaceAllString(s, r.mask)
+	}
+	return s
+}
+
+// ===================================================
+// Private Functions Area
+// ===================================================
+
+func (r *Redactor) matchesKey(key string) bool {
+	key = strings.ToLower(key)
+	if _, ok := r.keys[key]; ok {
+		return true
+	}
+	for _, p := range r.patterns {
+		if ok, _ := path.Match(p, key); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// redact walks v and reports whether anything had to change. When nothing
+// did, the original value is returned so its JSON shape stays exactly the same.
+// seen holds the pointers, maps and slices on the way down to v; running into
+// one of them again means a cycle, which gets cut off with cycleMarker.
+func (r *Redactor) redact(v reflect.Value, seen map[seenKey]struct{}) (any, bool) {
+	if !v.IsValid() {
+		return nil, false
+	}
+	original := v.Interface()
+
+	// Types that know how to serialize themselves (time.Time and friends)
+	// are left alone, poking at their insides would change their output
+	if v.Type().Implements(jsonMarshalerType) || v.Type().Implements(textMarshalerType) {
+		return original, false
+	}
+
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Map, reflect.Slice:
+		if v.IsNil() {
+			break
+		}
+		key := seenKey{ptr: v.Pointer(), typ: v.Type()}
+		if v.Kind() == reflect.Slice {
+			key.len = v.Len()
+		}
+		if _, ok := seen[key]; ok {
+			return cycleMarker, true
+		}
+		seen[key] = struct{}{}
+		defer delete(seen, key)
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		redacted := r.RedactString(v.String())
+		if redacted == v.String() {
+			return original, false
+		}
+		return redacted, true
+
+	case reflect.Pointer, reflect.Interface:
+		if v.IsNil() {
+			return original, false
+		}
+		inner, changed := r.redact(v.Elem(), seen)
+		if !changed {
+			return original, false
+		}
+		return inner, true
+
+	case reflect.Map:
+		if v.Type().Key().Kind() != reflect.String {
+			return original, false
+		}
+		out := make(map[string]any, v.Len())
+		changed := false
+		iter := v.MapRange()
+		for iter.Next() {
+			k := iter.Key().String()
+			if r.matchesKey(k) {
+				out[k] = r.mask
+				changed = true
+				continue
+			}
+			val, c := r.redact(iter.Value(), seen)
+			out[k] = val
+			changed = changed || c
+		}
+		if !changed {
+			return original, false
+		}
+		return out, true
+
+	case reflect.Slice, reflect.Array:
+		if v.Kind() == reflect.Slice && v.IsNil() {
+			return original, false
+		}
+		// []byte is JSON-encoded as base64, nothing for us to read there
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			return original, false
+		}
+		out := make([]any, v.Len())
+		changed := false
+		for i := 0; i < v.Len(); i++ {
+			val, c := r.redact(v.Index(i), seen)
+			out[i] = val
+			changed = changed || c
+		}
+		if !changed {
+			return original, false
+		}
+		return out, true
+
+	case reflect.Struct:
+		out := make(map[string]any, v.NumField())
+		changed := r.redactStruct(v, out, seen)
+		if !changed {
+			return original, false
+		}
+		return out, true
+
+	default:
+		return original, false
+	}
+}
+
+// redactStruct flattens a struct into out the way encoding/json would name
+// its fields, and reports whether anything had to be masked
+func (r *Redactor) redactStruct(v reflect.Value, out map[string]any, seen map[seenKey]struct{}) bool {
+	changed := false
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		fv := v.Field(i)
+
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+
+		// Embedded structs without a name get flattened, like encoding/json does
+		if field.Anonymous && name == "" {
+			ft := field.Type
+			if ft.Kind() == reflect.Pointer {
+				if fv.IsNil() {
+					continue
+				}
+				ft, fv = ft.Elem(), fv.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				changed = r.redactStruct(fv, out, seen) || changed
+				continue
+			}
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		if strings.Contains(opts, "omitempty") && fv.IsZero() {
+			continue
+		}
+
+		if r.matchesKey(name) {
+			out[name] = r.mask
+			changed = true
+			continue
+		}
+		val, c := r.redact(fv, seen)
+		out[name] = val
+		changed = changed || c
+	}
+	return changed
+}
@@ -0,0 +1,430 @@
+package logger
+
+// ===================================================
+// Imports Area
+// ===================================================
+
+import (
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// ===================================================
+// Definitions Area
+// ===================================================
+
+// RotationConfig decides when a log file has had enough
+type RotationConfig struct {
+	MaxSize        int64         // Roll once the file would grow past this many bytes, 0 means never
+	RotateEvery    time.Duration // Roll once the file has been open this long, 0 means never
+	MaxBackups     int           // How many rolled files to keep around, 0 keeps them all
+	MaxAge         time.Duration // Delete rolled files older than this, 0 keeps them forever
+	Compress       bool          // Gzip rolled files, because disks aren't free
+	ReopenOnSIGHUP bool          // Play nice with logrotate and friends
+}
+
+// RotatingFile is an io.Writer that rolls its file by size and/or time,
+// keeps a bounded number of backups and can be told to reopen its path
+type RotatingFile struct {
+	mu       sync.Mutex
+	path     string
+	config   RotationConfig
+	file     *os.File
+	size     int64
+	openedAt time.Time
+	// background compression and cleanup, waited for by Close
+	mill   sync.WaitGroup
+	millMu sync.Mutex
+	// stops the SIGHUP listener, nil when not listening
+	stopSignals func()
+	// set while rotations keep failing, so stderr hears about it once
+	rotateFailing bool
+}
+
+// ===================================================
+// Declarations Area
+// ===================================================
+
+// backupTimeFormat is embedded in backup names and parsed back by cleanup
+const backupTimeFormat = "20060102T150405.000"
+
+var (
+	// the file installed by SetLogFile, closed when it gets replaced
+	activeLogFile   *RotatingFile
+	activeLogFileMu sync.Mutex
+)
+
+// ===================================================
+// Public Functions Area
+// ===================================================
+
+// NewRotatingFile opens (or creates) path for appending and rolls it
+// according to config
+func NewRotatingFile(path string, config RotationConfig) (*RotatingFile, error) {
+	f := &RotatingFile{path: path, config: config}
+	if err := f.open(); err != nil {
+		return nil, err
+	}
+	if config.ReopenOnSIGHUP {
+		f.ReopenOnSignal(syscall.SIGHUP)
+	}
+	return f, nil
+}
+
+// SetLogFile points the default logger at a rotating file and closes the one
+// it replaces, so reconfiguring doesn't leak descriptors
+func SetLogFile(path string, config RotationConfig) (*RotatingFile, error) {
+	f, err := NewRotatingFile(path, config)
+	if err != nil {
+		return nil, err
+	}
+	SetLogOutput(f)
+
+	activeLogFileMu.Lock()
+	previous := activeLogFile
+	activeLogFile = f
+	activeLogFileMu.Unlock()
+
+	if previous != nil {
+		_ = previous.Close()
+	}
+	return f, nil
+}
+
+func (f *RotatingFile) Write(p []byte) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.file == nil {
+		return 0, os.ErrClosed
+	}
+
+	if f.shouldRotate(int64(len(p))) {
+		if err := f.rotate(); err != nil {
+			if f.file == nil {
+				return 0, err
+			}
+			// Still writing to the old file, a full log beats a silent one
+			if !f.rotateFailing {
+				_, _ = fmt.Fprintf(os.Stderr, "Logging error: rotating %s: %v\n", f.path, err)
+			}
+			f.rotateFailing = true
+		} else {
+			f.rotateFailing = false
+		}
+	}
+
+	n, err := f.file.Write(p)
+	f.size += int64(n)
+	return n, err
+}
+
+// Rotate rolls the file right now, whatever the policy says
+func (f *RotatingFile) Rotate() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.file == nil {
+		return os.ErrClosed
+	}
+	return f.rotate()
+}
+
+// Reopen closes and reopens the same path without renaming anything.
+// This is what logrotate expects after it has moved the file away.
+func (f *RotatingFile) Reopen() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.file != nil {
+		if err := f.file.Close(); err != nil {
+			return err
+		}
+		f.file = nil
+	}
+	return f.open()
+}
+
+// ReopenOnSignal reopens the file whenever one of sigs arrives
+func (f *RotatingFile) ReopenOnSignal(sigs ...os.Signal) {
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, sigs...)
+	done := make(chan struct{})
+
+	go func() {
+		for {
+			select {
+			case <-sigChan:
+				if err := f.Reopen(); err != nil {
+					_, _ = fmt.Fprintf(os.Stderr, "Logging error: reopening %s: %v\n", f.path, err)
+				}
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	f.mu.Lock()
+	previous := f.stopSignals
+	f.stopSignals = func() {
+		signal.Stop(sigChan)
+		close(done)
+	}
+	f.mu.Unlock()
+
+	if previous != nil {
+		previous()
+	}
+}
+
+// Close stops listening for signals, waits for pending compression and
+// closes the file
+func (f *RotatingFile) Close() error {
+	f.mu.Lock()
+	stop := f.stopSignals
+	f.stopSignals = nil
+	file := f.file
+	f.file = nil
+	f.mu.Unlock()
+
+	if stop != nil {
+		stop()
+	}
+	f.mill.Wait()
+
+	if file == nil {
+		return nil
+	}
+	return file.Close()
+}
+
+// Path returns the path of the live file
+func (f *RotatingFile) Path() string {
+	return f.path
+}
+
+// Backups lists the rolled files, newest first
+func (f *RotatingFile) Backups() ([]string, error) {
+	backups, err := f.backups()
+	if err != nil {
+		return nil, err
+	}
+	paths := make([]string, len(backups))
+	for i, b := range backups {
+		paths[i] = b.path
+	}
+	return paths, nil
+}
+
+// ===================================================
+// Private Functions Area
+// ===================================================
+
+type backupFile struct {
+	path    string
+	created time.Time
+}
+
+func (f *RotatingFile) open() error {
+	if err := os.MkdirAll(filepath.Dir(f.path), 0755); err != nil {
+		return err
+	}
+	file, err := os.OpenFile(f.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		_ = file.Close()
+		return err
+	}
+	f.file = file
+	f.size = info.Size()
+	f.openedAt = time.Now()
+	return nil
+}
+
+func (f *RotatingFile) shouldRotate(incoming int64) bool {
+	// Never roll an empty file, an oversized write has to land somewhere
+	if f.size == 0 {
+		return false
+	}
+	if f.config.MaxSize > 0 && f.size+incoming > f.config.MaxSize {
+		return true
+	}
+	return f.config.RotateEvery > 0 && time.Since(f.openedAt) >= f.config.RotateEvery
+}
+
+// rotate must be called with f.mu held. If the file can't be rolled, the
+// original path is reopened so logging carries on, and the error returned.
+func (f *RotatingFile) rotate() error {
+	if f.file != nil {
+		if err := f.file.Close(); err != nil {
+			return err
+		}
+		f.file = nil
+	}
+
+	if _, err := os.Stat(f.path); err == nil {
+		if err := os.Rename(f.path, f.backupName(time.Now().UTC())); err != nil {
+			return f.reopenAfter(err)
+		}
+	}
+
+	if err := f.open(); err != nil {
+		return f.reopenAfter(err)
+	}
+
+	f.mill.Add(1)
+	go func() {
+		defer f.mill.Done()
+		f.millBackups()
+	}()
+	return nil
+}
+
+// reopenAfter gets the live path open again after a failed rotation, so
+// one bad rename doesn't leave every later Write with os.ErrClosed
+func (f *RotatingFile) reopenAfter(err error) error {
+	if openErr := f.open(); openErr != nil {
+		return errors.Join(err, openErr)
+	}
+	return err
+}
+
+// backupName turns app.log into app-20060102T150405.000.log, dodging
+// collisions when two rotations land in the same millisecond
+func (f *RotatingFile) backupName(t time.Time) string {
+	dir, prefix, ext := f.nameParts()
+	name := filepath.Join(dir, prefix+t.Format(backupTimeFormat)+ext)
+	for i := 1; ; i++ {
+		// Only a name that's actually there is taken; anything else Stat
+		// complains about is for Rename to report, not for us to loop on
+		_, errPlain := os.Stat(name)
+		_, errGzip := os.Stat(name + ".gz")
+		if errPlain != nil && errGzip != nil {
+			return name
+		}
+		name = filepath.Join(dir, fmt.Sprintf("%s%s.%d%s", prefix, t.Format(backupTimeFormat), i, ext))
+	}
+}
+
+func (f *RotatingFile) nameParts() (dir, prefix, ext string) {
+	dir = filepath.Dir(f.path)
+	base := filepath.Base(f.path)
+	ext = filepath.Ext(base)
+	prefix = strings.TrimSuffix(base, ext) + "-"
+	return dir, prefix, ext
+}
+
+// backups returns the rolled files, newest first
+func (f *RotatingFile) backups() ([]backupFile, error) {
+	dir, prefix, ext := f.nameParts()
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var backups []backupFile
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() || !strings.HasPrefix(name, prefix) {
+			continue
+		}
+		stamp := strings.TrimSuffix(strings.TrimSuffix(name, ".gz"), ext)
+		stamp = strings.TrimPrefix(stamp, prefix)
+		if len(stamp) < len(backupTimeFormat) {
+			continue
+		}
+		created, err := time.Parse(backupTimeFormat, stamp[:len(backupTimeFormat)])
+		if err != nil {
+			continue
+		}
+		backups = append(backups, backupFile{path: filepath.Join(dir, name), created: created})
+	}
+
+	sort.Slice(backups, func(i, j int) bool {
+		if !backups[i].created.Equal(backups[j].created) {
+			return backups[i].created.After(backups[j].created)
+		}
+		// Same millisecond: the one with the collision counter came later
+		return len(strings.TrimSuffix(backups[i].path, ".gz")) > len(strings.TrimSuffix(backups[j].path, ".gz"))
+	})
+	return backups, nil
+}
+
+// millBackups compresses fresh backups and throws out the ones past their prime
+func (f *RotatingFile) millBackups() {
+	f.millMu.Lock()
+	defer f.millMu.Unlock()
+
+	backups, err := f.backups()
+	if err != nil {
+		return
+	}
+
+	cutoff := time.Time{}
+	if f.config.MaxAge > 0 {
+		cutoff = time.Now().Add(-f.config.MaxAge)
+	}
+
+	for i, b := range backups {
+		expired := f.config.MaxBackups > 0 && i >= f.config.MaxBackups
+		if !cutoff.IsZero() && b.created.Before(cutoff) {
+			expired = true
+		}
+		if expired {
+			_ = os.Remove(b.path)
+			continue
+		}
+		if f.config.Compress && !strings.HasSuffix(b.path, ".gz") {
+			if err := compressFile(b.path); err != nil {
+				_, _ = fmt.Fprintf(os.Stderr, "Logging error: compressing %s: %v\n", b.path, err)
+			}
+		}
+	}
+}
+
+// compressFile gzips path into path.gz and removes the original
+func compressFile(path string) (err error) {
+	src, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	dst, err := os.OpenFile(path+".gz", os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			_ = os.Remove(path + ".gz")
+		}
+	}()
+
+	gz := gzip.NewWriter(dst)
+	if _, err = io.Copy(gz, src); err != nil {
+		_ = dst.Close()
+		return err
+	}
+	if err = gz.Close(); err != nil {
+		_ = dst.Close()
+		return err
+	}
+	if err = dst.Close(); err != nil {
+		return err
+	}
+	_ = src.Close()
+	return os.Remove(path)
+}
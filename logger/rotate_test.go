@@ -0,0 +1,128 @@
+package logger_test
+
+import (
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/theHamdiz/it/logger"
+)
+
+func TestRotatingFile_RollsBySize(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	f, err := logger.NewRotatingFile(path, logger.RotationConfig{MaxSize: 10})
+	if err != nil {
+		t.Fatalf("NewRotatingFile() error = %v", err)
+	}
+	defer f.Close()
+
+	for _, line := range []string{"first\n", "second\n", "third\n"} {
+		if _, err := f.Write([]byte(line)); err != nil {
+			t.Fatalf("Write() error = %v", err)
+		}
+	}
+
+	backups, err := f.Backups()
+	if err != nil {
+		t.Fatalf("Backups() error = %v", err)
+	}
+	if len(backups) != 2 {
+		t.Fatalf("Expected 2 backups, got %v", backups)
+	}
+
+	live, _ := os.ReadFile(path)
+	if string(live) != "third\n" {
+		t.Errorf("Expected live file to hold the last line, got %q", live)
+	}
+	newest, _ := os.ReadFile(backups[0])
+	if string(newest) != "second\n" {
+		t.Errorf("Expected newest backup to hold the second line, got %q", newest)
+	}
+}
+
+func TestRotatingFile_RollsByTime(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	f, err := logger.NewRotatingFile(path, logger.RotationConfig{RotateEvery: 20 * time.Millisecond})
+	if err != nil {
+		t.Fatalf("NewRotatingFile() error = %v", err)
+	}
+	defer f.Close()
+
+	_, _ = f.Write([]byte("old\n"))
+	time.Sleep(30 * time.Millisecond)
+	_, _ = f.Write([]byte("new\n"))
+
+	backups, _ := f.Backups()
+	if len(backups) != 1 {
+		t.Fatalf("Expected 1 backup, got %v", backups)
+	}
+}
+
+func TestRotatingFile_MaxBackups(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	f, err := logger.NewRotatingFile(path, logger.RotationConfig{MaxBackups: 2})
+	if err != nil {
+		t.Fatalf("NewRotatingFile() error = %v", err)
+	}
+
+	for i := 0; i < 5; i++ {
+		_, _ = f.Write([]byte("line\n"))
+		if err := f.Rotate(); err != nil {
+			t.Fatalf("Rotate() error = %v", err)
+		}
+	}
+	// Close waits for the cleanup to finish
+	_ = f.Close()
+
+	backups, _ := f.Backups()
+	if len(backups) != 2 {
+		t.Errorf("Expected 2 backups to survive, got %v", backups)
+	}
+}
+
+func TestRotatingFile_Compress(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	f, err := logger.NewRotatingFile(path, logger.RotationConfig{Compress: true})
+	if err != nil {
+		t.Fatalf("NewRotatingFile() error = %v", err)
+	}
+
+	_, _ = f.Write([]byte("squeeze me\n"))
+	_ = f.Rotate()
+	_ = f.Close()
+
+	backups, _ := f.Backups()
+	if len(backups) != 1 || !strings.HasSuffix(backups[0], ".log.gz") {
+		t.Fatalf("Expected one gzipped backup, got %v", backups)
+	}
+
+	file, err := os.Open(backups[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	gz, err := gzip.NewReader(file)
+	if err != nil {
+		t.Fatalf("Backup is not gzip: %v", err)
+	}
+	content, _ := io.ReadAll(gz)
+	if string(content) != "squeeze me\n" {
+		t.Errorf("Unexpected backup content %q", content)
+	}
+}
+
+func TestRotatingFile_WriteAfterClose(t *testing.T) {
+	f, err := logger.NewRotatingFile(filepath.Join(t.TempDir(), "app.log"), logger.RotationConfig{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = f.Close()
+
+	if _, err := f.Write([]byte("too late")); err == nil {
+		t.Error("Expected an error writing to a closed file")
+	}
+}
@@ -0,0 +1,105 @@
+//go:build unix
+
+package logger_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/theHamdiz/it/logger"
+)
+
+func TestRotatingFile_ReopenOnSIGHUP(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+	f, err := logger.NewRotatingFile(path, logger.RotationConfig{ReopenOnSIGHUP: true})
+	if err != nil {
+		t.Fatalf("NewRotatingFile() error = %v", err)
+	}
+	defer f.Close()
+
+	_, _ = f.Write([]byte("before\n"))
+	// Pretend to be logrotate
+	if err := os.Rename(path, filepath.Join(dir, "moved.log")); err != nil {
+		t.Fatal(err)
+	}
+	if err := syscall.Kill(os.Getpid(), syscall.SIGHUP); err != nil {
+		t.Skipf("cannot signal ourselves: %v", err)
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for {
+		if _, err := os.Stat(path); err == nil {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("Expected the file to be reopened after SIGHUP")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	_, _ = f.Write([]byte("after\n"))
+	content, _ := os.ReadFile(path)
+	if string(content) != "after\n" {
+		t.Errorf("Expected fresh file content, got %q", content)
+	}
+}
+
+func TestRotatingFile_KeepsLoggingWhenRenameFails(t *testing.T) {
+	// A directory we can't create backups in, and for root, who can write
+	// anywhere, a name with no room left for the backup timestamp
+	unwritable := func(t *testing.T) string {
+		if os.Geteuid() == 0 {
+			t.Skip("root ignores directory permissions")
+		}
+		dir := t.TempDir()
+		path := filepath.Join(dir, "app.log")
+		if err := os.WriteFile(path, nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chmod(dir, 0555); err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { _ = os.Chmod(dir, 0755) })
+		return path
+	}
+	tooLong := func(t *testing.T) string {
+		return filepath.Join(t.TempDir(), strings.Repeat("a", 240)+".log")
+	}
+
+	for name, setup := range map[string]func(*testing.T) string{
+		"unwritable directory": unwritable,
+		"name too long":        tooLong,
+	} {
+		t.Run(name, func(t *testing.T) {
+			path := setup(t)
+			f, err := logger.NewRotatingFile(path, logger.RotationConfig{MaxSize: 10})
+			if err != nil {
+				t.Fatalf("NewRotatingFile() error = %v", err)
+			}
+			defer f.Close()
+
+			if _, err := f.Write([]byte("first line\n")); err != nil {
+				t.Fatalf("Write() error = %v", err)
+			}
+			if err := f.Rotate(); err == nil {
+				t.Fatal("Expected Rotate to fail")
+			}
+			// The size limit keeps trying to roll, the lines keep landing
+			for _, line := range []string{"second\n", "third\n"} {
+				if _, err := f.Write([]byte(line)); err != nil {
+					t.Fatalf("Expected writes to carry on after a failed rotation, got %v", err)
+				}
+			}
+
+			content, _ := os.ReadFile(path)
+			if string(content) != "first line\nsecond\nthird\n" {
+				t.Errorf("Expected every line in the original file, got %q", content)
+			}
+		})
+	}
+}
@@ -0,0 +1,279 @@
+package logger
+
+// ===================================================
+// Imports Area
+// ===================================================
+
+import (
+	"container/list"
+	"fmt"
+	"math"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ===================================================
+// Definitions Area
+// ===================================================
+
+// SamplingConfig keeps chatty code from drowning everyone else. Both
+// policies are optional and can be combined; AUDIT and FATAL entries are
+// never sampled, because legal and post-mortems both need every word.
+type SamplingConfig struct {
+	// Log the first First entries of each message per Interval...
+	First int
+	// ...then every Thereafter-th one. Zero drops the rest of the interval.
+	Thereafter int
+	// How long a message key's counter lives, defaults to one second
+	Interval time.Duration
+	// How many message keys we track at once, defaults to 1024.
+	// The least recently seen key is forgotten first.
+	MaxKeys int
+	// Token-bucket caps on lines per second, per level
+	Limits map[LogLevel]RateLimit
+	// How often the "suppressed N messages" summary goes out, defaults to 10s
+	SummaryInterval time.Duration
+}
+
+// RateLimit is a token bucket: PerSecond tokens trickle in, up to Burst
+type RateLimit struct {
+	PerSecond float64
+	Burst     int
+}
+
+// sampler holds the state behind a SamplingConfig
+type sampler struct {
+	config SamplingConfig
+	// where summaries go, bypassing the sampler
+	output *atomic.Value
+
+	mu      sync.Mutex
+	keys    map[string]*list.Element
+	lru     *list.List
+	buckets map[LogLevel]*tokenBucket
+	// suppressed since the last summary, per level
+	pending      map[LogLevel]uint64
+	summaryTimer *time.Timer
+
+	suppressed atomic.Uint64
+}
+
+type sampleCounter struct {
+	key         string
+	windowStart time.Time
+	count       int
+}
+
+type tokenBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// ===================================================
+// Declarations Area
+// ===================================================
+
+const (
+	defaultSampleInterval  = time.Second
+	defaultSampleMaxKeys   = 1024
+	defaultSummaryInterval = 10 * time.Second
+)
+
+// ===================================================
+// Public Functions Area
+// ===================================================
+
+// SetSampling installs sampling on the default logger
+func SetSampling(config SamplingConfig) {
+	defaultLogger.SetSampling(config)
+}
+
+// SetSampling installs sampling on this logger's whole tree: the children
+// made with With share it, and so do the parent this logger came from and
+// its other children, much like they share handlers. It survives SetOutput
+// and SetHandlers; use ClearSampling to get the firehose back.
+func (l *Logger) SetSampling(config SamplingConfig) {
+	if config.Interval <= 0 {
+		config.Interval = defaultSampleInterval
+	}
+	if config.MaxKeys <= 0 {
+		config.MaxKeys = defaultSampleMaxKeys
+	}
+	if config.SummaryInterval <= 0 {
+		config.SummaryInterval = defaultSummaryInterval
+	}
+
+	s := &sampler{
+		config:  config,
+		output:  l.output,
+		keys:    make(map[string]*list.Element),
+		lru:     list.New(),
+		buckets: make(map[LogLevel]*tokenBucket),
+		pending: make(map[LogLevel]uint64),
+	}
+	l.updatePipeline(func(p *pipeline) {
+		p.sampler = s
+	})
+}
+
+// ClearSampling removes sampling, pending summaries are discarded
+func (l *Logger) ClearSampling() {
+	var previous *sampler
+	l.updatePipeline(func(p *pipeline) {
+		previous, p.sampler = p.sampler, nil
+	})
+	if previous != nil {
+		previous.stop()
+	}
+}
+
+// Suppressed reports how many entries sampling has swallowed so far
+func (l *Logger) Suppressed() uint64 {
+	if s := l.pipeline().sampler; s != nil {
+		return s.suppressed.Load()
+	}
+	return 0
+}
+
+// ===================================================
+// Private Functions Area
+// ===================================================
+
+// allow decides whether r makes it to the handlers
+func (s *sampler) allow(r Record) bool {
+	if r.Level == LevelAudit || r.Level == LevelFatal {
+		return true
+	}
+
+	now := time.Now()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if (s.config.First > 0 || s.config.Thereafter > 0) && !s.sample(r, now) {
+		s.suppress(r.Level)
+		return false
+	}
+	if limit, ok := s.config.Limits[r.Level]; ok && !s.take(r.Level, limit, now) {
+		s.suppress(r.Level)
+		return false
+	}
+	return true
+}
+
+// sample applies first-N-then-every-Mth, must be called with s.mu held
+func (s *sampler) sample(r Record, now time.Time) bool {
+	// Same idea as onceMessages, but the level is part of the key
+	key := r.Level.String() + "\x00" + r.Message
+
+	var c *sampleCounter
+	if el, ok := s.keys[key]; ok {
+		s.lru.MoveToFront(el)
+		c = el.Value.(*sampleCounter)
+		if now.Sub(c.windowStart) >= s.config.Interval {
+			c.windowStart = now
+			c.count = 0
+		}
+	} else {
+		// Expired keys are the cheapest to forget, then the least recently seen
+		s.evictExpired(now)
+		for s.lru.Len() >= s.config.MaxKeys {
+			s.forget(s.lru.Back())
+		}
+		c = &sampleCounter{key: key, windowStart: now}
+		s.keys[key] = s.lru.PushFront(c)
+	}
+
+	c.count++
+	if c.count <= s.config.First {
+		return true
+	}
+	return s.config.Thereafter > 0 && (c.count-s.config.First)%s.config.Thereafter == 0
+}
+
+// evictExpired drops keys whose window ended, oldest first
+func (s *sampler) evictExpired(now time.Time) {
+	for el := s.lru.Back(); el != nil; el = s.lru.Back() {
+		if now.Sub(el.Value.(*sampleCounter).windowStart) < s.config.Interval {
+			return
+		}
+		s.forget(el)
+	}
+}
+
+func (s *sampler) forget(el *list.Element) {
+	delete(s.keys, el.Value.(*sampleCounter).key)
+	s.lru.Remove(el)
+}
+
+// take draws a token from the level's bucket, must be called with s.mu held
+func (s *sampler) take(level LogLevel, limit RateLimit, now time.Time) bool {
+	burst := float64(limit.Burst)
+	if burst <= 0 {
+		burst = math.Max(1, math.Ceil(limit.PerSecond))
+	}
+
+	b, ok := s.buckets[level]
+	if !ok {
+		b = &tokenBucket{tokens: burst, last: now}
+		s.buckets[level] = b
+	}
+
+	b.tokens = math.Min(burst, b.tokens+now.Sub(b.last).Seconds()*limit.PerSecond)
+	b.last = now
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
+
+// suppress counts a swallowed entry and makes sure a summary is on its way
+func (s *sampler) suppress(level LogLevel) {
+	s.suppressed.Add(1)
+	s.pending[level]++
+	if s.summaryTimer == nil {
+		s.summaryTimer = time.AfterFunc(s.config.SummaryInterval, s.summarize)
+	}
+}
+
+// summarize reports what was swallowed since the last summary
+func (s *sampler) summarize() {
+	s.mu.Lock()
+	s.summaryTimer = nil
+	var total uint64
+	data := make(map[string]any, len(s.pending)+1)
+	for level, n := range s.pending {
+		total += n
+		data[level.String()] = n
+	}
+	clear(s.pending)
+	s.mu.Unlock()
+
+	if total == 0 {
+		return
+	}
+	data["suppressed"] = total
+
+	p, _ := s.output.Load().(pipeline)
+	// A different sampler means we were replaced, the news is stale
+	if p.sampler != s {
+		return
+	}
+	p.write(Record{
+		Time:       time.Now(),
+		Level:      LevelWarning,
+		Message:    fmt.Sprintf("suppressed %d messages", total),
+		Data:       data,
+		Structured: true,
+	})
+}
+
+func (s *sampler) stop() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.summaryTimer != nil {
+		s.summaryTimer.Stop()
+		s.summaryTimer = nil
+	}
+}
@@ -0,0 +1,167 @@
+package logger_test
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/theHamdiz/it/logger"
+)
+
+func TestSampling_FirstThenEveryMth(t *testing.T) {
+	logger_, buf := newTestLogger()
+	logger_.SetSampling(logger.SamplingConfig{First: 2, Thereafter: 3, Interval: time.Hour, SummaryInterval: time.Hour})
+	defer logger_.ClearSampling()
+
+	for i := 0; i < 11; i++ {
+		logger_.Info("hot loop")
+	}
+
+	// 1, 2, then 5, 8, 11
+	if got := strings.Count(buf.String(), "hot loop"); got != 5 {
+		t.Errorf("Expected 5 sampled lines, got %d: %q", got, buf.String())
+	}
+	if logger_.Suppressed() != 6 {
+		t.Errorf("Expected 6 suppressed, got %d", logger_.Suppressed())
+	}
+}
+
+func TestSampling_KeysAreIndependent(t *testing.T) {
+	logger_, buf := newTestLogger()
+	logger_.SetSampling(logger.SamplingConfig{First: 1, Interval: time.Hour, SummaryInterval: time.Hour})
+	defer logger_.ClearSampling()
+
+	logger_.Info("a")
+	logger_.Info("a")
+	logger_.Warn("a")
+	logger_.Info("b")
+
+	output := buf.String()
+	if strings.Count(output, "INFO] a") != 1 || !strings.Contains(output, "WARN] a") || !strings.Contains(output, "INFO] b") {
+		t.Errorf("Expected one line per key, got %q", output)
+	}
+}
+
+func TestSampling_IntervalResets(t *testing.T) {
+	logger_, buf := newTestLogger()
+	logger_.SetSampling(logger.SamplingConfig{First: 1, Interval: 20 * time.Millisecond, SummaryInterval: time.Hour})
+	defer logger_.ClearSampling()
+
+	logger_.Info("tick")
+	logger_.Info("tick")
+	time.Sleep(30 * time.Millisecond)
+	logger_.Info("tick")
+
+	if got := strings.Count(buf.String(), "tick"); got != 2 {
+		t.Errorf("Expected the window to reset, got %d lines", got)
+	}
+}
+
+func TestSampling_BoundedKeys(t *testing.T) {
+	logger_, buf := newTestLogger()
+	logger_.SetSampling(logger.SamplingConfig{First: 1, MaxKeys: 2, Interval: time.Hour, SummaryInterval: time.Hour})
+	defer logger_.ClearSampling()
+
+	logger_.Info("one")
+	logger_.Info("two")
+	// Pushes "one" out of memory, so it counts as new again
+	logger_.Info("three")
+	logger_.Info("one")
+
+	if got := strings.Count(buf.String(), "INFO] one"); got != 2 {
+		t.Errorf("Expected the evicted key to start over, got %d lines", got)
+	}
+}
+
+func TestSampling_RateLimitPerLevel(t *testing.T) {
+	logger_, buf := newTestLogger()
+	logger_.SetSampling(logger.SamplingConfig{
+		Limits:          map[logger.LogLevel]logger.RateLimit{logger.LevelDebug: {PerSecond: 0.001, Burst: 3}},
+		SummaryInterval: time.Hour,
+	})
+	defer logger_.ClearSampling()
+
+	for i := 0; i < 10; i++ {
+		logger_.Debugf("debug %d", i)
+		logger_.Infof("info %d", i)
+	}
+
+	output := buf.String()
+	if got := strings.Count(output, "DEBUG]"); got != 3 {
+		t.Errorf("Expected burst of 3 DEBUG lines, got %d", got)
+	}
+	if got := strings.Count(output, "INFO]"); got != 10 {
+		t.Errorf("Expected INFO to be unlimited, got %d", got)
+	}
+}
+
+func TestSampling_AuditIsNeverSampled(t *testing.T) {
+	logger_, buf := newTestLogger()
+	logger_.SetSampling(logger.SamplingConfig{First: 1, Interval: time.Hour, SummaryInterval: time.Hour})
+	defer logger_.ClearSampling()
+
+	for i := 0; i < 3; i++ {
+		logger_.StructuredLog(logger.LevelAudit, "who did it", nil)
+	}
+	if got := strings.Count(buf.String(), "who did it"); got != 3 {
+		t.Errorf("Expected every audit entry, got %d", got)
+	}
+}
+
+func TestSampling_Summary(t *testing.T) {
+	sink := &SyncWriter{}
+	logger_ := logger.NewLoggerWithLevelAndOutput(logger.LevelTrace, sink)
+	logger_.SetSampling(logger.SamplingConfig{First: 1, Interval: time.Hour, SummaryInterval: 20 * time.Millisecond})
+	defer logger_.ClearSampling()
+
+	for i := 0; i < 5; i++ {
+		logger_.Error("boom")
+	}
+
+	waitFor(t, func() bool { return strings.Contains(sink.String(), "suppressed 4 messages") })
+
+	for _, line := range strings.Split(sink.String(), "\n") {
+		if !strings.Contains(line, "suppressed") {
+			continue
+		}
+		var entry map[string]interface{}
+		if err := json.Unmarshal([]byte(line), &entry); err != nil {
+			t.Fatalf("Expected a structured summary, got %q", line)
+		}
+		data := entry["data"].(map[string]interface{})
+		if data["ERROR"].(float64) != 4 {
+			t.Errorf("Expected per-level counts, got %v", data)
+		}
+	}
+}
+
+func TestSampling_SurvivesSetOutput(t *testing.T) {
+	logger_, _ := newTestLogger()
+	logger_.SetSampling(logger.SamplingConfig{First: 1, Interval: time.Hour, SummaryInterval: time.Hour})
+	defer logger_.ClearSampling()
+
+	sink := &testWriter{}
+	logger_.SetOutput(sink)
+	logger_.Info("again")
+	logger_.Info("again")
+
+	if got := strings.Count(sink.String(), "again"); got != 1 {
+		t.Errorf("Expected sampling to survive SetOutput, got %d lines", got)
+	}
+}
+
+func TestSampling_AppliesToTheWholeTree(t *testing.T) {
+	parent, buf := newTestLogger()
+	child := parent.With(map[string]any{"request_id": "r-1"})
+	child.SetSampling(logger.SamplingConfig{First: 1, Interval: time.Hour, SummaryInterval: time.Hour})
+	defer child.ClearSampling()
+
+	parent.Info("hot loop")
+	parent.Info("hot loop")
+	parent.With(map[string]any{"request_id": "r-2"}).Info("hot loop")
+
+	if got := strings.Count(buf.String(), "hot loop"); got != 1 {
+		t.Errorf("Expected sampling set on a child to cover its parent and siblings, got %d lines", got)
+	}
+}
@@ -0,0 +1,266 @@
+package logger
+
+// ===================================================
+// Imports Area
+// ===================================================
+
+import (
+	"context"
+	"log/slog"
+	"sort"
+)
+
+// ===================================================
+// Definitions Area
+// ===================================================
+
+// SlogHandler is a slog.Handler that writes through a Logger, so slog users
+// get our levels, colors and StructuredLogEntry JSON for free
+type SlogHandler struct {
+	logger *Logger
+	// attributes collected by WithAttrs, already nested under their groups
+	attrs map[string]any
+	// open groups, outermost first
+	groups []string
+}
+
+// SlogBridge is a Handler that forwards records to any slog.Handler, for
+// when the slog crowd owns the output and we just want to join in
+type SlogBridge struct {
+	handler slog.Handler
+}
+
+// ===================================================
+// Declarations Area
+// ===================================================
+
+// slog only knows DEBUG, INFO, WARN and ERROR, so our extra levels live in
+// the gaps it leaves on purpose
+const (
+	SlogLevelTrace = slog.Level(-8)
+	SlogLevelFatal = slog.Level(12)
+	SlogLevelAudit = slog.Level(16)
+)
+
+// ===================================================
+// Public Functions Area
+// ===================================================
+
+// SlogLevel maps a LogLevel onto the slog scale
+func (l LogLevel) SlogLevel() slog.Level {
+	switch l {
+	case LevelTrace:
+		return SlogLevelTrace
+	case LevelDebug:
+		return slog.LevelDebug
+	case LevelInfo:
+		return slog.LevelInfo
+	case LevelWarning:
+		return slog.LevelWarn
+	case LevelError:
+		return slog.LevelError
+	case LevelFatal:
+		return SlogLevelFatal
+	case LevelAudit:
+		return SlogLevelAudit
+	default:
+		return slog.LevelInfo
+	}
+}
+
+// LevelFromSlog maps any slog level onto the closest LogLevel at or below it
+func LevelFromSlog(level slog.Level) LogLevel {
+	switch {
+	case level <= SlogLevelTrace:
+		return LevelTrace
+	case level < slog.LevelInfo:
+		return LevelDebug
+	case level < slog.LevelWarn:
+		return LevelInfo
+	case level < slog.LevelError:
+		return LevelWarning
+	case level < SlogLevelFatal:
+		return LevelError
+	case level < SlogLevelAudit:
+		return LevelFatal
+	default:
+		return LevelAudit
+	}
+}
+
+// SlogReplaceAttr is meant for slog.HandlerOptions.ReplaceAttr. It prints our
+// custom levels as TRACE, FATAL and AUDIT instead of "DEBUG-4" and friends.
+func SlogReplaceAttr(groups []string, a slog.Attr) slog.Attr {
+	if len(groups) > 0 || a.Key != slog.LevelKey {
+		return a
+	}
+	if level, ok := a.Value.Any().(slog.Level); ok {
+		switch level {
+		case SlogLevelTrace, SlogLevelFatal, SlogLevelAudit:
+			a.Value = slog.StringValue(LevelFromSlog(level).String())
+		}
+	}
+	return a
+}
+
+// NewSlogHandler creates a slog.Handler backed by l
+func NewSlogHandler(l *Logger) *SlogHandler {
+	return &SlogHandler{logger: l}
+}
+
+// NewSlogLogger is the shortcut for slog.New(NewSlogHandler(l))
+func NewSlogLogger(l *Logger) *slog.Logger {
+	return slog.New(NewSlogHandler(l))
+}
+
+func (h *SlogHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return h.logger.shouldLog(LevelFromSlog(level))
+}
+
+// Handle turns the slog record into one of ours. Records carrying attributes
+// come out as structured entries, bare messages as plain lines.
+func (h *SlogHandler) Handle(ctx context.Context, sr slog.Record) error {
+	data := cloneTree(h.attrs)
+	if sr.NumAttrs() > 0 {
+		if data == nil {
+			data = make(map[string]any)
+		}
+		target := groupMap(data, h.groups)
+		sr.Attrs(func(a slog.Attr) bool {
+			addAttr(target, a)
+			return true
+		})
+	}
+
+	r := Record{
+		Time:       sr.Time,
+		Level:      LevelFromSlog(sr.Level),
+		Message:    sr.Message,
+		Data:       h.logger.mergeFields(data),
+		Structured: len(data) > 0,
+		PC:         sr.PC,
+	}
+	// slog.InfoContext and friends get trace correlation for free
+	h.logger.stampTrace(ctx, &r)
+	h.logger.dispatch(r)
+	return nil
+}
+
+func (h *SlogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	if len(attrs) == 0 {
+		return h
+	}
+	tree := cloneTree(h.attrs)
+	if tree == nil {
+		tree = make(map[string]any)
+	}
+	target := groupMap(tree, h.groups)
+	for _, a := range attrs {
+		addAttr(target, a)
+	}
+	return &SlogHandler{logger: h.logger, attrs: tree, groups: h.groups}
+}
+
+func (h *SlogHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	groups := make([]string, len(h.groups), len(h.groups)+1)
+	copy(groups, h.groups)
+	return &SlogHandler{logger: h.logger, attrs: h.attrs, groups: append(groups, name)}
+}
+
+// NewSlogBridge creates a Handler that forwards to h
+func NewSlogBridge(h slog.Handler) *SlogBridge {
+	return &SlogBridge{handler: h}
+}
+
+// NewLoggerFromSlog builds a Logger on top of any slog.Handler. The logger
+// lets everything through and leaves the level decision to h.
+func NewLoggerFromSlog(h slog.Handler) *Logger {
+	return NewLoggerWithHandlers(LevelTrace, NewSlogBridge(h))
+}
+
+func (b *SlogBridge) Enabled(level LogLevel) bool {
+	return b.handler.Enabled(context.Background(), level.SlogLevel())
+}
+
+func (b *SlogBridge) Handle(r Record) error {
+	sr := slog.NewRecord(r.Time, r.Level.SlogLevel(), r.Message, r.PC)
+
+	// Sorted, because map order is a lie and slog output should be stable
+	keys := make([]string, 0, len(r.Data))
+	for k := range r.Data {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		sr.AddAttrs(slog.Any(k, r.Data[k]))
+	}
+	if r.Error != nil {
+		sr.AddAttrs(slog.Any("error", r.Error))
+	}
+	if r.TraceID != "" {
+		sr.AddAttrs(slog.String("trace_id", r.TraceID), slog.String("span_id", r.SpanID))
+	}
+
+	return b.handler.Handle(context.Background(), sr)
+}
+
+// ===================================================
+// Private Functions Area
+// ===================================================
+
+// addAttr stores a resolved attribute in m, expanding groups into nested maps
+func addAttr(m map[string]any, a slog.Attr) {
+	a.Value = a.Value.Resolve()
+	if a.Equal(slog.Attr{}) {
+		return
+	}
+
+	if a.Value.Kind() != slog.KindGroup {
+		m[a.Key] = a.Value.Any()
+		return
+	}
+
+	group := a.Value.Group()
+	if len(group) == 0 {
+		return
+	}
+	target := m
+	// Groups without a key are inlined, as slog says they should be
+	if a.Key != "" {
+		target = groupMap(m, []string{a.Key})
+	}
+	for _, ga := range group {
+		addAttr(target, ga)
+	}
+}
+
+// groupMap walks (and creates) the nested maps for groups inside m
+func groupMap(m map[string]any, groups []string) map[string]any {
+	for _, g := range groups {
+		next, ok := m[g].(map[string]any)
+		if !ok {
+			next = make(map[string]any)
+			m[g] = next
+		}
+		m = next
+	}
+	return m
+}
+
+// cloneTree deep-copies the nested maps so handlers stay immutable
+func cloneTree(m map[string]any) map[string]any {
+	if m == nil {
+		return nil
+	}
+	clone := make(map[string]any, len(m))
+	for k, v := range m {
+		if nested, ok := v.(map[string]any); ok {
+			v = cloneTree(nested)
+		}
+		clone[k] = v
+	}
+	return clone
+}
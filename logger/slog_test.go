@@ -0,0 +1,135 @@
+package logger_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"testing"
+	"testing/slogtest"
+
+	"github.com/theHamdiz/it/logger"
+)
+
+func TestSlogHandler_Conformance(t *testing.T) {
+	buf := &bytes.Buffer{}
+	logger_ := logger.NewLoggerWithHandlers(logger.LevelTrace,
+		logger.NewWriterHandler(buf, logger.NewJSONFormatter(), logger.LevelTrace),
+	)
+
+	results := func() []map[string]any {
+		var ms []map[string]any
+		for _, line := range bytes.Split(buf.Bytes(), []byte{'\n'}) {
+			if len(line) == 0 {
+				continue
+			}
+			var entry logger.StructuredLogEntry
+			if err := json.Unmarshal(line, &entry); err != nil {
+				t.Fatal(err)
+			}
+			// Reshape our entry into the flat layout slogtest expects
+			m := map[string]any{
+				slog.LevelKey:   entry.Level,
+				slog.MessageKey: entry.Message,
+			}
+			if !entry.Timestamp.IsZero() {
+				m[slog.TimeKey] = entry.Timestamp
+			}
+			for k, v := range entry.Data {
+				m[k] = v
+			}
+			ms = append(ms, m)
+		}
+		return ms
+	}
+
+	if err := slogtest.TestHandler(logger.NewSlogHandler(logger_), results); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestSlogHandler_RespectsLoggerLevel(t *testing.T) {
+	buf := &bytes.Buffer{}
+	logger_ := logger.NewLoggerWithLevelAndOutput(logger.LevelWarning, buf)
+	slogger := logger.NewSlogLogger(logger_)
+
+	slogger.Info("nope")
+	slogger.Warn("yep")
+
+	if strings.Contains(buf.String(), "nope") {
+		t.Errorf("Expected INFO to be filtered, got %q", buf.String())
+	}
+	if !strings.Contains(buf.String(), "[🚧 WARN] yep") {
+		t.Errorf("Expected plain emoji line for attribute-less record, got %q", buf.String())
+	}
+}
+
+func TestSlogHandler_AttrsBecomeStructuredData(t *testing.T) {
+	logger_, buf := newTestLogger()
+	slogger := logger.NewSlogLogger(logger_.With(map[string]any{"svc": "api"}))
+
+	slogger.With("request_id", "r-9").WithGroup("http").Info("served", "status", 200)
+
+	var entry map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+		t.Fatalf("Failed to unmarshal JSON: %v (%q)", err, buf.String())
+	}
+	data := entry["data"].(map[string]interface{})
+	if data["svc"] != "api" || data["request_id"] != "r-9" {
+		t.Errorf("Expected logger and slog fields, got %v", data)
+	}
+	if data["http"].(map[string]interface{})["status"].(float64) != 200 {
+		t.Errorf("Expected grouped status, got %v", data["http"])
+	}
+}
+
+func TestSlogBridge_CustomLevels(t *testing.T) {
+	buf := &bytes.Buffer{}
+	h := slog.NewJSONHandler(buf, &slog.HandlerOptions{
+		Level:       logger.SlogLevelTrace,
+		ReplaceAttr: logger.SlogReplaceAttr,
+	})
+	logger_ := logger.NewLoggerFromSlog(h)
+
+	logger_.Trace("tracing")
+	logger_.StructuredLog(logger.LevelAudit, "audited", map[string]any{"actor": "root"})
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("Expected 2 lines, got %q", buf.String())
+	}
+
+	var trace, audit map[string]interface{}
+	_ = json.Unmarshal([]byte(lines[0]), &trace)
+	_ = json.Unmarshal([]byte(lines[1]), &audit)
+	if trace["level"] != "TRACE" || trace["msg"] != "tracing" {
+		t.Errorf("Unexpected trace record: %v", trace)
+	}
+	if audit["level"] != "AUDIT" || audit["actor"] != "root" {
+		t.Errorf("Unexpected audit record: %v", audit)
+	}
+}
+
+func TestSlogBridge_DefersToSlogLevel(t *testing.T) {
+	buf := &bytes.Buffer{}
+	logger_ := logger.NewLoggerFromSlog(slog.NewTextHandler(buf, &slog.HandlerOptions{Level: slog.LevelWarn}))
+
+	logger_.Info("ignored")
+	if buf.Len() != 0 {
+		t.Errorf("Expected slog handler to filter INFO, got %q", buf.String())
+	}
+}
+
+func TestLevelMapping_RoundTrip(t *testing.T) {
+	for _, level := range []logger.LogLevel{
+		logger.LevelTrace, logger.LevelDebug, logger.LevelInfo, logger.LevelWarning,
+		logger.LevelError, logger.LevelFatal, logger.LevelAudit,
+	} {
+		if got := logger.LevelFromSlog(level.SlogLevel()); got != level {
+			t.Errorf("Round trip of %v gave %v", level, got)
+		}
+	}
+	if got := logger.LevelFromSlog(slog.Level(-6)); got != logger.LevelDebug {
+		t.Errorf("Expected in-between level to round down to DEBUG, got %v", got)
+	}
+}
@@ -0,0 +1,209 @@
+package logger_test
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/theHamdiz/it/logger"
+)
+
+func TestSyslogHandler_UDP(t *testing.T) {
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Skipf("cannot listen on udp: %v", err)
+	}
+	defer pc.Close()
+
+	h, err := logger.NewSyslogHandler(logger.SyslogConfig{
+		Network:  "udp",
+		Address:  pc.LocalAddr().String(),
+		Facility: logger.FacilityLocal0,
+		AppName:  "billing",
+		Hostname: "box",
+	})
+	if err != nil {
+		t.Fatalf("NewSyslogHandler() error = %v", err)
+	}
+	defer h.Close()
+
+	l := logger.NewLoggerWithHandlers(logger.LevelTrace, h)
+	l.StructuredInfo("charged", map[string]any{"amount": 42, "note": `say "hi"]`})
+
+	msg := readPacket(t, pc)
+	// local0 * 8 + info
+	if !strings.HasPrefix(msg, "<134>1 ") {
+		t.Errorf("Expected PRI 134 and version 1, got %q", msg)
+	}
+	for _, want := range []string{
+		" box billing ",
+		`[data@32473 amount="42" note="say \"hi\"\]"`,
+		"\xEF\xBB\xBFcharged",
+	} {
+		if !strings.Contains(msg, want) {
+			t.Errorf("Expected %q in %q", want, msg)
+		}
+	}
+}
+
+func TestSyslogHandler_TypedNilPointers(t *testing.T) {
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Skipf("cannot listen on udp: %v", err)
+	}
+	defer pc.Close()
+
+	h, err := logger.NewSyslogHandler(logger.SyslogConfig{Network: "udp", Address: pc.LocalAddr().String()})
+	if err != nil {
+		t.Fatalf("NewSyslogHandler() error = %v", err)
+	}
+	defer h.Close()
+
+	err = h.Handle(logger.Record{Time: time.Now(), Level: logger.LevelInfo, Message: "req",
+		Data: map[string]any{"url": (*url.URL)(nil)}})
+	if err != nil {
+		t.Fatalf("Handle() error = %v", err)
+	}
+	if msg := readPacket(t, pc); !strings.Contains(msg, `url="null"`) {
+		t.Errorf("Expected the typed nil as null, got %q", msg)
+	}
+}
+
+func TestSyslogHandler_Severities(t *testing.T) {
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Skipf("cannot listen on udp: %v", err)
+	}
+	defer pc.Close()
+
+	h, err := logger.NewSyslogHandler(logger.SyslogConfig{Network: "udp", Address: pc.LocalAddr().String()})
+	if err != nil {
+		t.Fatalf("NewSyslogHandler() error = %v", err)
+	}
+	defer h.Close()
+	l := logger.NewLoggerWithHandlers(logger.LevelTrace, h)
+
+	tests := []struct {
+		log  func(string)
+		want string
+	}{
+		{l.Debug, "<15>1 "},
+		{l.Info, "<14>1 "},
+		{l.Warn, "<12>1 "},
+		{l.Error, "<11>1 "},
+	}
+	for _, tt := range tests {
+		tt.log("hello")
+		if msg := readPacket(t, pc); !strings.HasPrefix(msg, tt.want) {
+			t.Errorf("Expected prefix %q, got %q", tt.want, msg)
+		}
+	}
+}
+
+func TestSyslogHandler_TCPOctetCounting(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Skipf("cannot listen on tcp: %v", err)
+	}
+	defer ln.Close()
+
+	received := make(chan []string, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		received <- readFrames(conn, 2)
+	}()
+
+	h, err := logger.NewSyslogHandler(logger.SyslogConfig{Network: "tcp", Address: ln.Addr().String()})
+	if err != nil {
+		t.Fatalf("NewSyslogHandler() error = %v", err)
+	}
+	l := logger.NewLoggerWithHandlers(logger.LevelTrace, h)
+	l.Info("first")
+	l.ErrorErrCtx(context.Background(), context.Canceled, "second", nil)
+	_ = h.Close()
+
+	select {
+	case frames := <-received:
+		if len(frames) != 2 {
+			t.Fatalf("Expected 2 frames, got %q", frames)
+		}
+		if !strings.HasSuffix(frames[0], "first") {
+			t.Errorf("Unexpected first frame %q", frames[0])
+		}
+		if !strings.Contains(frames[1], `error="context canceled"`) {
+			t.Errorf("Expected the error as an SD-PARAM, got %q", frames[1])
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Timed out waiting for syslog frames")
+	}
+}
+
+func TestSyslogHandler_Level(t *testing.T) {
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Skipf("cannot listen on udp: %v", err)
+	}
+	defer pc.Close()
+
+	h, err := logger.NewSyslogHandler(logger.SyslogConfig{
+		Network: "udp",
+		Address: pc.LocalAddr().String(),
+		Level:   logger.LevelWarning,
+	})
+	if err != nil {
+		t.Fatalf("NewSyslogHandler() error = %v", err)
+	}
+	defer h.Close()
+
+	if h.Enabled(logger.LevelInfo) || !h.Enabled(logger.LevelError) {
+		t.Error("Expected only WARNING and above to be enabled")
+	}
+	h.SetLevel(logger.LevelDebug)
+	if !h.Enabled(logger.LevelDebug) {
+		t.Error("Expected SetLevel to lower the threshold")
+	}
+}
+
+// readPacket returns the next datagram, failing the test if none shows up
+func readPacket(t *testing.T, pc net.PacketConn) string {
+	t.Helper()
+	buf := make([]byte, 64*1024)
+	_ = pc.SetReadDeadline(time.Now().Add(2 * time.Second))
+	n, _, err := pc.ReadFrom(buf)
+	if err != nil {
+		t.Fatalf("Expected a syslog packet: %v", err)
+	}
+	return string(buf[:n])
+}
+
+// readFrames reads up to n octet-counted frames
+func readFrames(conn net.Conn, n int) []string {
+	var frames []string
+	br := bufio.NewReader(conn)
+	for len(frames) < n {
+		prefix, err := br.ReadString(' ')
+		if err != nil {
+			break
+		}
+		size, err := strconv.Atoi(strings.TrimSpace(prefix))
+		if err != nil {
+			break
+		}
+		frame := make([]byte, size)
+		if _, err := io.ReadFull(br, frame); err != nil {
+			break
+		}
+		frames = append(frames, string(frame))
+	}
+	return frames
+}
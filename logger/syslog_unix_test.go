@@ -0,0 +1,218 @@
+//go:build unix
+
+package logger_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/theHamdiz/it/logger"
+)
+
+func TestSyslogHandler_UnixDatagram(t *testing.T) {
+	path := shortSocketPath(t, "syslog.sock")
+	pc, err := net.ListenPacket("unixgram", path)
+	if err != nil {
+		t.Skipf("cannot listen on unixgram: %v", err)
+	}
+	defer pc.Close()
+
+	h, err := logger.NewSyslogHandler(logger.SyslogConfig{Network: "unixgram", Address: path, Facility: logger.FacilityDaemon})
+	if err != nil {
+		t.Fatalf("NewSyslogHandler() error = %v", err)
+	}
+	defer h.Close()
+
+	logger.NewLoggerWithHandlers(logger.LevelTrace, h).Warn("disk almost full")
+	msg := readPacket(t, pc)
+	// daemon * 8 + warning
+	if !strings.HasPrefix(msg, "<28>1 ") || !strings.HasSuffix(msg, "disk almost full") {
+		t.Errorf("Unexpected message %q", msg)
+	}
+}
+
+func TestJournaldHandler(t *testing.T) {
+	path := shortSocketPath(t, "journal.sock")
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: path, Net: "unixgram"})
+	if err != nil {
+		t.Skipf("cannot listen on unixgram: %v", err)
+	}
+	defer conn.Close()
+
+	h, err := logger.NewJournaldHandler(logger.JournaldConfig{Path: path, Identifier: "billing"})
+	if err != nil {
+		t.Fatalf("NewJournaldHandler() error = %v", err)
+	}
+	defer h.Close()
+
+	l := logger.NewLoggerWithHandlers(logger.LevelTrace, h)
+	l.StructuredError("charge failed", map[string]any{
+		"order-id": 7,
+		"_PID":     "forged",
+		"reply":    "line one\nline two",
+	})
+
+	buf := make([]byte, 64*1024)
+	_ = conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	n, err := conn.Read(buf)
+	if err != nil {
+		t.Fatalf("Expected a journal datagram: %v", err)
+	}
+	fields := parseJournal(t, buf[:n])
+
+	want := map[string]string{
+		"MESSAGE":           "charge failed",
+		"PRIORITY":          "3",
+		"SYSLOG_IDENTIFIER": "billing",
+		"ORDER_ID":          "7",
+		"PID":               "forged",
+		"REPLY":             "line one\nline two",
+	}
+	for k, v := range want {
+		if fields[k] != v {
+			t.Errorf("Expected %s=%q, got %q", k, v, fields[k])
+		}
+	}
+	if _, ok := fields["_PID"]; ok {
+		t.Error("Expected trusted fields not to be forgeable")
+	}
+	if !strings.HasSuffix(fields["CODE_FILE"], "syslog_unix_test.go") || fields["CODE_LINE"] == "" {
+		t.Errorf("Expected the call site in CODE_FILE/CODE_LINE, got %q:%q", fields["CODE_FILE"], fields["CODE_LINE"])
+	}
+}
+
+func TestJournaldHandler_ReservedFieldNames(t *testing.T) {
+	path := shortSocketPath(t, "journal.sock")
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: path, Net: "unixgram"})
+	if err != nil {
+		t.Skipf("cannot listen on unixgram: %v", err)
+	}
+	defer conn.Close()
+
+	h, err := logger.NewJournaldHandler(logger.JournaldConfig{Path: path, Identifier: "billing"})
+	if err != nil {
+		t.Fatalf("NewJournaldHandler() error = %v", err)
+	}
+	defer h.Close()
+
+	l := logger.NewLoggerWithHandlers(logger.LevelTrace, h)
+	l.StructuredInfo("charged", map[string]any{
+		"message":           "forged",
+		"priority":          0,
+		"syslog_identifier": "someone-else",
+		"code_file":         "elsewhere.go",
+	})
+
+	buf := make([]byte, 64*1024)
+	_ = conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	n, err := conn.Read(buf)
+	if err != nil {
+		t.Fatalf("Expected a journal datagram: %v", err)
+	}
+	fields := parseJournal(t, buf[:n])
+
+	want := map[string]string{
+		"MESSAGE":                "charged",
+		"PRIORITY":               "6",
+		"SYSLOG_IDENTIFIER":      "billing",
+		"DATA_MESSAGE":           "forged",
+		"DATA_PRIORITY":          "0",
+		"DATA_SYSLOG_IDENTIFIER": "someone-else",
+		"DATA_CODE_FILE":         "elsewhere.go",
+	}
+	for k, v := range want {
+		if fields[k] != v {
+			t.Errorf("Expected %s=%q, got %q", k, v, fields[k])
+		}
+	}
+	if !strings.HasSuffix(fields["CODE_FILE"], "syslog_unix_test.go") {
+		t.Errorf("Expected CODE_FILE to keep the call site, got %q", fields["CODE_FILE"])
+	}
+}
+
+func TestJournaldHandler_TypedNilPointers(t *testing.T) {
+	path := shortSocketPath(t, "journal.sock")
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: path, Net: "unixgram"})
+	if err != nil {
+		t.Skipf("cannot listen on unixgram: %v", err)
+	}
+	defer conn.Close()
+
+	h, err := logger.NewJournaldHandler(logger.JournaldConfig{Path: path})
+	if err != nil {
+		t.Fatalf("NewJournaldHandler() error = %v", err)
+	}
+	defer h.Close()
+
+	err = h.Handle(logger.Record{Time: time.Now(), Level: logger.LevelInfo, Message: "req",
+		Data: map[string]any{"url": (*url.URL)(nil)}})
+	if err != nil {
+		t.Fatalf("Handle() error = %v", err)
+	}
+
+	buf := make([]byte, 64*1024)
+	_ = conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	n, err := conn.Read(buf)
+	if err != nil {
+		t.Fatalf("Expected a journal datagram: %v", err)
+	}
+	if fields := parseJournal(t, buf[:n]); fields["URL"] != "null" {
+		t.Errorf("Expected URL=null, got %q", fields["URL"])
+	}
+}
+
+func TestJournaldHandler_NoJournal(t *testing.T) {
+	h, err := logger.NewJournaldHandler(logger.JournaldConfig{Path: filepath.Join(t.TempDir(), "missing.sock")})
+	if err != nil {
+		t.Fatalf("NewJournaldHandler() error = %v", err)
+	}
+	defer h.Close()
+
+	if err := h.Handle(logger.Record{Time: time.Now(), Level: logger.LevelInfo, Message: "lost"}); err == nil {
+		t.Error("Expected an error when nobody is listening")
+	}
+}
+
+// shortSocketPath keeps socket paths under the ~104 byte limit some systems have
+func shortSocketPath(t *testing.T, name string) string {
+	t.Helper()
+	dir, err := os.MkdirTemp("", "it")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = os.RemoveAll(dir) })
+	return filepath.Join(dir, name)
+}
+
+// parseJournal decodes the native protocol, both field forms
+func parseJournal(t *testing.T, data []byte) map[string]string {
+	t.Helper()
+	fields := map[string]string{}
+	for len(data) > 0 {
+		nl := bytes.IndexByte(data, '\n')
+		if nl < 0 {
+			t.Fatalf("Unterminated field in %q", data)
+		}
+		line := data[:nl]
+		data = data[nl+1:]
+		if key, value, ok := bytes.Cut(line, []byte("=")); ok {
+			fields[string(key)] = string(value)
+			continue
+		}
+		if len(data) < 8 {
+			t.Fatalf("Truncated binary field %q", line)
+		}
+		size := binary.LittleEndian.Uint64(data[:8])
+		data = data[8:]
+		fields[string(line)] = string(data[:size])
+		data = data[size+1:]
+	}
+	return fields
+}
@@ -0,0 +1,227 @@
+package logger
+
+// ===================================================
+// Imports Area
+// ===================================================
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// ===================================================
+// Definitions Area
+// ===================================================
+
+// SpanContext identifies the span an entry was logged in, IDs in lowercase hex
+type SpanContext struct {
+	TraceID string
+	SpanID  string
+	Sampled bool
+}
+
+// TraceExtractor finds the current span in a context. Plug in one backed by
+// your tracing library of choice; the default reads what ContextWithSpan
+// (or TraceMiddleware) put there.
+type TraceExtractor interface {
+	Extract(ctx context.Context) (SpanContext, bool)
+}
+
+// TraceExtractorFunc lets a plain function be a TraceExtractor
+type TraceExtractorFunc func(ctx context.Context) (SpanContext, bool)
+
+// spanCtxKey is unexported for the same reason ctxKey is
+type spanCtxKey struct{}
+
+// contextExtractor reads spans stored by ContextWithSpan
+type contextExtractor struct{}
+
+// ===================================================
+// Declarations Area
+// ===================================================
+
+// TraceparentHeader is the W3C Trace Context header name
+const TraceparentHeader = "traceparent"
+
+// ErrInvalidTraceparent is returned for headers that don't follow the W3C spec
+var ErrInvalidTraceparent = errors.New("invalid traceparent header")
+
+// ===================================================
+// Public Functions Area
+// ===================================================
+
+// ParseTraceparent parses a W3C traceparent header, e.g.
+// "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+func ParseTraceparent(header string) (SpanContext, error) {
+	parts := strings.Split(strings.TrimSpace(header), "-")
+	if len(parts) < 4 {
+		return SpanContext{}, ErrInvalidTraceparent
+	}
+	version, traceID, spanID, flags := parts[0], parts[1], parts[2], parts[3]
+
+	// Version ff is forbidden, and version 00 has exactly four fields;
+	// later versions may append more, which we're told to ignore
+	if !isLowerHex(version, 2) || version == "ff" || (version == "00" && len(parts) != 4) {
+		return SpanContext{}, ErrInvalidTraceparent
+	}
+	if !isLowerHex(traceID, 32) || traceID == strings.Repeat("0", 32) {
+		return SpanContext{}, ErrInvalidTraceparent
+	}
+	if !isLowerHex(spanID, 16) || spanID == strings.Repeat("0", 16) {
+		return SpanContext{}, ErrInvalidTraceparent
+	}
+	if !isLowerHex(flags, 2) {
+		return SpanContext{}, ErrInvalidTraceparent
+	}
+
+	b, _ := hex.DecodeString(flags)
+	return SpanContext{TraceID: traceID, SpanID: spanID, Sampled: b[0]&1 == 1}, nil
+}
+
+// Traceparent renders sc as a version 00 W3C traceparent header
+func (sc SpanContext) Traceparent() string {
+	flags := "00"
+	if sc.Sampled {
+		flags = "01"
+	}
+	return fmt.Sprintf("00-%s-%s-%s", sc.TraceID, sc.SpanID, flags)
+}
+
+// IsValid reports whether sc has both IDs
+func (sc SpanContext) IsValid() bool {
+	return sc.TraceID != "" && sc.SpanID != ""
+}
+
+// ContextWithSpan stores sc in ctx for the default extractor to find
+func ContextWithSpan(ctx context.Context, sc SpanContext) context.Context {
+	return context.WithValue(ctx, spanCtxKey{}, sc)
+}
+
+// SpanFromContext returns the span ContextWithSpan stored in ctx
+func SpanFromContext(ctx context.Context) (SpanContext, bool) {
+	if ctx == nil {
+		return SpanContext{}, false
+	}
+	sc, ok := ctx.Value(spanCtxKey{}).(SpanContext)
+	return sc, ok && sc.IsValid()
+}
+
+// TraceMiddleware picks up the traceparent header of incoming requests, so
+// everything logged with the request's context carries its trace
+func TraceMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if sc, err := ParseTraceparent(r.Header.Get(TraceparentHeader)); err == nil {
+			r = r.WithContext(ContextWithSpan(r.Context(), sc))
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (f TraceExtractorFunc) Extract(ctx context.Context) (SpanContext, bool) {
+	return f(ctx)
+}
+
+// SetTraceExtractor changes how the default logger finds spans
+func SetTraceExtractor(e TraceExtractor) {
+	defaultLogger.SetTraceExtractor(e)
+}
+
+// SetTraceExtractor changes how this logger's whole tree finds spans, the
+// parent it came from and its With children included; nil goes back to
+// reading what ContextWithSpan stored
+func (l *Logger) SetTraceExtractor(e TraceExtractor) {
+	l.updatePipeline(func(p *pipeline) {
+		p.extractor = e
+	})
+}
+
+func (l *Logger) TraceCtx(ctx context.Context, msg string) {
+	l.logCtx(ctx, LevelTrace, msg, nil, false)
+}
+
+func (l *Logger) DebugCtx(ctx context.Context, msg string) {
+	l.logCtx(ctx, LevelDebug, msg, nil, false)
+}
+
+// InfoCtx is Info with the trace of ctx attached
+func (l *Logger) InfoCtx(ctx context.Context, msg string) {
+	l.logCtx(ctx, LevelInfo, msg, nil, false)
+}
+
+func (l *Logger) WarnCtx(ctx context.Context, msg string) {
+	l.logCtx(ctx, LevelWarning, msg, nil, false)
+}
+
+func (l *Logger) ErrorCtx(ctx context.Context, msg string) {
+	l.logCtx(ctx, LevelError, msg, nil, false)
+}
+
+// StructuredLogCtx is StructuredLog with the trace of ctx attached
+func (l *Logger) StructuredLogCtx(ctx context.Context, level LogLevel, msg string, data map[string]any) {
+	l.logCtx(ctx, level, msg, data, true)
+}
+
+func (l *Logger) StructuredInfoCtx(ctx context.Context, msg string, data map[string]any) {
+	l.logCtx(ctx, LevelInfo, msg, data, true)
+}
+
+func (l *Logger) StructuredDebugCtx(ctx context.Context, msg string, data map[string]any) {
+	l.logCtx(ctx, LevelDebug, msg, data, true)
+}
+
+func (l *Logger) StructuredErrorCtx(ctx context.Context, msg string, data map[string]any) {
+	l.logCtx(ctx, LevelError, msg, data, true)
+}
+
+// ErrorErrCtx is ErrorErr with the trace of ctx attached
+func (l *Logger) ErrorErrCtx(ctx context.Context, err error, msg string, fields map[string]any) {
+	l.errorErr(ctx, err, msg, fields)
+}
+
+// ===================================================
+// Private Functions Area
+// ===================================================
+
+func (contextExtractor) Extract(ctx context.Context) (SpanContext, bool) {
+	return SpanFromContext(ctx)
+}
+
+// logCtx is log and structured rolled into one, plus the trace
+func (l *Logger) logCtx(ctx context.Context, level LogLevel, msg string, data map[string]any, structured bool) {
+	if !l.shouldLog(level) {
+		return
+	}
+	r := l.record(level, msg, data, structured, 2)
+	l.stampTrace(ctx, &r)
+	l.dispatch(r)
+}
+
+// stampTrace copies the span found in ctx onto r
+func (l *Logger) stampTrace(ctx context.Context, r *Record) {
+	if ctx == nil {
+		return
+	}
+	var extractor TraceExtractor = contextExtractor{}
+	if e := l.pipeline().extractor; e != nil {
+		extractor = e
+	}
+	if sc, ok := extractor.Extract(ctx); ok && sc.IsValid() {
+		r.TraceID, r.SpanID = sc.TraceID, sc.SpanID
+	}
+}
+
+func isLowerHex(s string, n int) bool {
+	if len(s) != n {
+		return false
+	}
+	for _, c := range s {
+		if (c < '0' || c > '9') && (c < 'a' || c > 'f') {
+			return false
+		}
+	}
+	return true
+}
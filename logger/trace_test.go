@@ -0,0 +1,125 @@
+package logger_test
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/theHamdiz/it/logger"
+	"github.com/theHamdiz/it/logger/logtest"
+)
+
+const (
+	testTraceID     = "4bf92f3577b34da6a3ce929d0e0e4736"
+	testSpanID      = "00f067aa0ba902b7"
+	testTraceparent = "00-" + testTraceID + "-" + testSpanID + "-01"
+)
+
+func TestParseTraceparent(t *testing.T) {
+	sc, err := logger.ParseTraceparent(testTraceparent)
+	if err != nil {
+		t.Fatalf("ParseTraceparent() error = %v", err)
+	}
+	if sc.TraceID != testTraceID || sc.SpanID != testSpanID || !sc.Sampled {
+		t.Errorf("Unexpected span context %+v", sc)
+	}
+	if sc.Traceparent() != testTraceparent {
+		t.Errorf("Expected a round trip, got %q", sc.Traceparent())
+	}
+
+	// Future versions may carry extra fields
+	if _, err := logger.ParseTraceparent("cc-" + testTraceID + "-" + testSpanID + "-00-extra"); err != nil {
+		t.Errorf("Expected future versions to parse, got %v", err)
+	}
+
+	for _, bad := range []string{
+		"",
+		"00-" + testTraceID + "-" + testSpanID,
+		"ff-" + testTraceID + "-" + testSpanID + "-01",
+		"00-" + strings.Repeat("0", 32) + "-" + testSpanID + "-01",
+		"00-" + testTraceID + "-" + strings.Repeat("0", 16) + "-01",
+		"00-" + strings.ToUpper(testTraceID) + "-" + testSpanID + "-01",
+		"00-" + testTraceID + "-" + testSpanID + "-01-extra",
+		"00-" + testTraceID + "-" + testSpanID + "-zz",
+	} {
+		if _, err := logger.ParseTraceparent(bad); err == nil {
+			t.Errorf("Expected %q to be rejected", bad)
+		}
+	}
+}
+
+func TestCtxMethods_InjectTrace(t *testing.T) {
+	logger_, buf := newTestLogger()
+	sc, _ := logger.ParseTraceparent(testTraceparent)
+	ctx := logger.ContextWithSpan(context.Background(), sc)
+
+	logger_.StructuredLogCtx(ctx, logger.LevelInfo, "structured", map[string]any{"k": "v"})
+	var entry map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+		t.Fatalf("Failed to unmarshal JSON: %v", err)
+	}
+	if entry["trace_id"] != testTraceID || entry["span_id"] != testSpanID {
+		t.Errorf("Expected trace fields in the entry, got %v", entry)
+	}
+
+	buf.Reset()
+	logger_.InfoCtx(ctx, "plain")
+	if !strings.Contains(buf.String(), "plain trace_id="+testTraceID+" span_id="+testSpanID) {
+		t.Errorf("Expected trace fields on the plain line, got %q", buf.String())
+	}
+
+	buf.Reset()
+	logger_.InfoCtx(context.Background(), "no trace")
+	if strings.Contains(buf.String(), "trace_id") {
+		t.Errorf("Expected no trace fields without a span, got %q", buf.String())
+	}
+}
+
+func TestCtxMethods_CustomExtractor(t *testing.T) {
+	logger_, rec := logtest.New()
+	type otelKey struct{}
+	logger_.SetTraceExtractor(logger.TraceExtractorFunc(func(ctx context.Context) (logger.SpanContext, bool) {
+		id, ok := ctx.Value(otelKey{}).(string)
+		return logger.SpanContext{TraceID: id, SpanID: "1111111111111111"}, ok
+	}))
+
+	ctx := context.WithValue(context.Background(), otelKey{}, testTraceID)
+	logger_.WarnCtx(ctx, "from otel")
+	logger_.ErrorErrCtx(ctx, nil, "with error", nil)
+
+	for _, e := range rec.Entries() {
+		if e.TraceID != testTraceID || e.SpanID != "1111111111111111" {
+			t.Errorf("Expected the custom extractor to be used, got %+v", e)
+		}
+	}
+	if rec.Entries()[0].Caller == "" || !strings.HasPrefix(rec.Entries()[0].Caller, "trace_test.go:") {
+		t.Errorf("Expected the caller to be this file, got %q", rec.Entries()[0].Caller)
+	}
+}
+
+func TestTraceMiddlewareAndSlog(t *testing.T) {
+	logger_, rec := logtest.New()
+	slogger := logger.NewSlogLogger(logger_)
+
+	h := logger.TraceMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		logger_.InfoCtx(r.Context(), "handled")
+		slogger.InfoContext(r.Context(), "via slog", slog.Int("status", 200))
+	}))
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set(logger.TraceparentHeader, testTraceparent)
+	h.ServeHTTP(httptest.NewRecorder(), req)
+
+	entries := rec.Entries()
+	if len(entries) != 2 {
+		t.Fatalf("Expected 2 entries, got %d", len(entries))
+	}
+	for _, e := range entries {
+		if e.TraceID != testTraceID || e.SpanID != testSpanID {
+			t.Errorf("Expected %q to carry the request's trace, got %+v", e.Message, e)
+		}
+	}
+}
@@ -0,0 +1,184 @@
+package retry
+
+import (
+	"math"
+	"math/rand"
+	"time"
+)
+
+// Backoff decides how long to wait before each retry. retry is 1 for the
+// wait before the second attempt, 2 before the third, and so on; previous
+// is whatever Next returned last time, zero before the first retry.
+// Implementations keep no state of their own, so one Backoff can be shared
+// by any number of concurrent retry loops.
+type Backoff interface {
+	Next(retry int, previous time.Duration) time.Duration
+}
+
+// BackoffFunc lets a plain function be a Backoff
+type BackoffFunc func(retry int, previous time.Duration) time.Duration
+
+// ConstantBackoff waits the same Delay every time, the "have you tried
+// turning it off and on again" of backoffs
+type ConstantBackoff struct {
+	Delay time.Duration
+}
+
+// LinearBackoff waits Initial, then Initial+Increment, Initial+2*Increment...
+type LinearBackoff struct {
+	Initial   time.Duration
+	Increment time.Duration
+	// Zero means no cap
+	Max time.Duration
+}
+
+// ExponentialBackoff waits Initial, then multiplies by Multiplier each time.
+// RandomFactor adds up to that fraction of the delay on top, never less.
+type ExponentialBackoff struct {
+	Initial time.Duration
+	// Zero means no cap
+	Max time.Duration
+	// Anything below 1 means 2
+	Multiplier   float64
+	RandomFactor float64
+}
+
+// FullJitterBackoff waits anywhere between zero and the exponential delay.
+// Clients stop stampeding in lockstep, at the price of sometimes not waiting at all.
+type FullJitterBackoff struct {
+	Base time.Duration
+	Max  time.Duration
+}
+
+// EqualJitterBackoff waits at least half the exponential delay, the rest
+// left to chance
+type EqualJitterBackoff struct {
+	Base time.Duration
+	Max  time.Duration
+}
+
+// DecorrelatedJitterBackoff waits a random time between Base and three
+// times the previous wait, so each client wanders off on its own schedule
+type DecorrelatedJitterBackoff struct {
+	Base time.Duration
+	Max  time.Duration
+}
+
+// FibonacciBackoff waits Initial, Initial, 2*Initial, 3*Initial, 5*Initial...
+// gentler than doubling, for when your patience follows the golden ratio
+type FibonacciBackoff struct {
+	Initial time.Duration
+	// Zero means no cap
+	Max time.Duration
+}
+
+// Constant waits delay between attempts
+func Constant(delay time.Duration) ConstantBackoff {
+	return ConstantBackoff{Delay: delay}
+}
+
+// Linear waits initial plus increment per retry, up to max
+func Linear(initial, increment, max time.Duration) LinearBackoff {
+	return LinearBackoff{Initial: initial, Increment: increment, Max: max}
+}
+
+// Exponential doubles the wait every retry, up to max
+func Exponential(initial, max time.Duration) ExponentialBackoff {
+	return ExponentialBackoff{Initial: initial, Max: max, Multiplier: 2}
+}
+
+// FullJitter is the "full jitter" strategy, base doubling up to max
+func FullJitter(base, max time.Duration) FullJitterBackoff {
+	return FullJitterBackoff{Base: base, Max: max}
+}
+
+// EqualJitter is the "equal jitter" strategy, base doubling up to max
+func EqualJitter(base, max time.Duration) EqualJitterBackoff {
+	return EqualJitterBackoff{Base: base, Max: max}
+}
+
+// DecorrelatedJitter is the "decorrelated jitter" strategy, never above max
+func DecorrelatedJitter(base, max time.Duration) DecorrelatedJitterBackoff {
+	return DecorrelatedJitterBackoff{Base: base, Max: max}
+}
+
+// Fibonacci grows the wait along the Fibonacci sequence, up to max
+func Fibonacci(initial, max time.Duration) FibonacciBackoff {
+	return FibonacciBackoff{Initial: initial, Max: max}
+}
+
+func (f BackoffFunc) Next(retry int, previous time.Duration) time.Duration {
+	return f(retry, previous)
+}
+
+func (b ConstantBackoff) Next(int, time.Duration) time.Duration {
+	return b.Delay
+}
+
+func (b LinearBackoff) Next(retry int, _ time.Duration) time.Duration {
+	return capDelay(float64(b.Initial)+float64(retry-1)*float64(b.Increment), b.Max)
+}
+
+func (b ExponentialBackoff) Next(retry int, _ time.Duration) time.Duration {
+	multiplier := b.Multiplier
+	if multiplier < 1 {
+		multiplier = 2
+	}
+	delay := capDelay(float64(b.Initial)*math.Pow(multiplier, float64(retry-1)), b.Max)
+	if b.RandomFactor > 0 {
+		delay = capDelay(float64(delay)+rand.Float64()*float64(delay)*b.RandomFactor, 0)
+	}
+	return delay
+}
+
+func (b FullJitterBackoff) Next(retry int, _ time.Duration) time.Duration {
+	ceiling := capDelay(float64(b.Base)*math.Pow(2, float64(retry-1)), b.Max)
+	return randomBetween(0, ceiling)
+}
+
+func (b EqualJitterBackoff) Next(retry int, _ time.Duration) time.Duration {
+	ceiling := capDelay(float64(b.Base)*math.Pow(2, float64(retry-1)), b.Max)
+	return ceiling/2 + randomBetween(0, ceiling-ceiling/2)
+}
+
+func (b DecorrelatedJitterBackoff) Next(_ int, previous time.Duration) time.Duration {
+	if previous < b.Base {
+		previous = b.Base
+	}
+	return capDelay(float64(randomBetween(b.Base, capDelay(float64(previous)*3, 0))), b.Max)
+}
+
+func (b FibonacciBackoff) Next(retry int, _ time.Duration) time.Duration {
+	previous, current := 0.0, 1.0
+	for i := 1; i < retry && !math.IsInf(current, 1); i++ {
+		previous, current = current, previous+current
+	}
+	return capDelay(float64(b.Initial)*current, b.Max)
+}
+
+// capDelay turns d into a Duration no bigger than max (zero meaning no
+// max), and no bigger than a Duration can hold when the math got excited
+func capDelay(d float64, max time.Duration) time.Duration {
+	if d <= 0 {
+		return 0
+	}
+	if max > 0 && d > float64(max) {
+		return max
+	}
+	if d >= math.MaxInt64 {
+		return math.MaxInt64
+	}
+	return time.Duration(d)
+}
+
+// randomBetween returns a random duration in [low, high]
+func randomBetween(low, high time.Duration) time.Duration {
+	if high <= low {
+		return low
+	}
+	span := int64(high - low)
+	if span < math.MaxInt64 {
+		span++
+	}
+	return low + time.Duration(rand.Int63n(span))
+}
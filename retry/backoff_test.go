@@ -0,0 +1,102 @@
+package retry_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/theHamdiz/it/retry"
+)
+
+func TestDeterministicBackoffs(t *testing.T) {
+	ms := time.Millisecond
+	tests := []struct {
+		name    string
+		backoff retry.Backoff
+		want    []time.Duration
+	}{
+		{"constant", retry.Constant(5 * ms), []time.Duration{5 * ms, 5 * ms, 5 * ms}},
+		{"linear", retry.Linear(10*ms, 5*ms, 22*ms), []time.Duration{10 * ms, 15 * ms, 20 * ms, 22 * ms}},
+		{"exponential", retry.Exponential(10*ms, 50*ms), []time.Duration{10 * ms, 20 * ms, 40 * ms, 50 * ms}},
+		{"exponential x3", retry.ExponentialBackoff{Initial: ms, Multiplier: 3}, []time.Duration{ms, 3 * ms, 9 * ms}},
+		{"fibonacci", retry.Fibonacci(ms, 6*ms), []time.Duration{ms, ms, 2 * ms, 3 * ms, 5 * ms, 6 * ms}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var previous time.Duration
+			for i, want := range tt.want {
+				previous = tt.backoff.Next(i+1, previous)
+				if previous != want {
+					t.Errorf("Retry %d: expected %v, got %v", i+1, want, previous)
+				}
+			}
+		})
+	}
+}
+
+func TestJitterBackoffsStayInRange(t *testing.T) {
+	base, max := 10*time.Millisecond, 100*time.Millisecond
+	for i := 0; i < 200; i++ {
+		for retryNo := 1; retryNo <= 6; retryNo++ {
+			ceiling := base << (retryNo - 1)
+			if ceiling > max {
+				ceiling = max
+			}
+			if d := retry.FullJitter(base, max).Next(retryNo, 0); d < 0 || d > ceiling {
+				t.Fatalf("FullJitter retry %d: %v outside [0, %v]", retryNo, d, ceiling)
+			}
+			if d := retry.EqualJitter(base, max).Next(retryNo, 0); d < ceiling/2 || d > ceiling {
+				t.Fatalf("EqualJitter retry %d: %v outside [%v, %v]", retryNo, d, ceiling/2, ceiling)
+			}
+		}
+	}
+
+	decorrelated := retry.DecorrelatedJitter(base, max)
+	var previous time.Duration
+	for i := 1; i <= 200; i++ {
+		d := decorrelated.Next(i, previous)
+		upper := 3 * previous
+		if upper < 3*base {
+			upper = 3 * base
+		}
+		if upper > max {
+			upper = max
+		}
+		if d < base || d > upper {
+			t.Fatalf("DecorrelatedJitter after %v: %v outside [%v, %v]", previous, d, base, upper)
+		}
+		previous = d
+	}
+}
+
+func TestExponentialBackoff_Overflow(t *testing.T) {
+	if d := retry.Exponential(time.Second, 0).Next(200, 0); d <= 0 {
+		t.Errorf("Expected a huge positive delay, got %v", d)
+	}
+	if d := retry.Fibonacci(time.Second, time.Minute).Next(5000, 0); d != time.Minute {
+		t.Errorf("Expected the cap, got %v", d)
+	}
+}
+
+func TestWithBackoff_UsesConfiguredBackoff(t *testing.T) {
+	var retries []int
+	config := retry.Config{
+		Attempts: 4,
+		Backoff: retry.BackoffFunc(func(n int, _ time.Duration) time.Duration {
+			retries = append(retries, n)
+			return time.Millisecond
+		}),
+	}
+
+	_, err := retry.WithBackoff(context.Background(), config, func(context.Context) (int, error) {
+		return 0, errors.New("nope")
+	})
+	if err == nil {
+		t.Fatal("Expected the last error back")
+	}
+	if len(retries) != 3 || retries[0] != 1 || retries[2] != 3 {
+		t.Errorf("Expected the backoff to be asked for retries 1..3, got %v", retries)
+	}
+}
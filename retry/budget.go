@@ -0,0 +1,148 @@
+package retry
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/theHamdiz/it/clock"
+)
+
+// BudgetConfig says how many retries a Budget hands out
+type BudgetConfig struct {
+	// Retries allowed per request, 0.1 means retries may add at most 10%
+	// on top of the traffic. Defaults to 0.1.
+	Ratio float64
+	// Retries allowed regardless of traffic, so a quiet service can still
+	// retry its one request a minute. Zero means none.
+	MinRetriesPerSecond float64
+	// How far back requests and retries are counted, defaults to 10s
+	Window time.Duration
+	// Clock tells the time, nil means the real one
+	Clock clock.Clock
+}
+
+// BudgetStats is what a Budget has been up to
+type BudgetStats struct {
+	// Since the budget was created
+	Requests uint64
+	Granted  uint64
+	Denied   uint64
+	// Within the current window
+	WindowRequests int
+	WindowRetries  int
+}
+
+// Budget caps retries across every WithBackoff call sharing it. Each call
+// deposits Ratio tokens, each retry takes one out, and tokens older than
+// Window expire. When an outage makes everything fail, retries dry up
+// instead of multiplying the load on whatever is already down.
+type Budget struct {
+	config BudgetConfig
+	clock  clock.Clock
+
+	mu      sync.Mutex
+	buckets []budgetBucket
+	width   time.Duration
+	// index and start time of the bucket being filled
+	head      int
+	headStart time.Time
+
+	stats BudgetStats
+}
+
+// budgetBucket counts one slice of the window
+type budgetBucket struct {
+	requests int
+	retries  int
+}
+
+// ErrBudgetExhausted is the Limit of a RetryError when the Budget said no
+var ErrBudgetExhausted = errors.New("retry: retry budget exhausted")
+
+const (
+	defaultBudgetRatio  = 0.1
+	defaultBudgetWindow = 10 * time.Second
+	// The window slides in steps of Window/budgetBuckets
+	budgetBuckets = 10
+)
+
+// NewBudget creates a budget, share it through Config.Budget
+func NewBudget(config BudgetConfig) *Budget {
+	if config.Ratio <= 0 {
+		config.Ratio = defaultBudgetRatio
+	}
+	if config.Window <= 0 {
+		config.Window = defaultBudgetWindow
+	}
+	return &Budget{
+		config:    config,
+		clock:     clock.Or(config.Clock),
+		buckets:   make([]budgetBucket, budgetBuckets),
+		width:     max(config.Window/budgetBuckets, 1),
+		headStart: clock.Or(config.Clock).Now(),
+	}
+}
+
+// RecordRequest counts a first attempt. WithBackoff does this for you.
+func (b *Budget) RecordRequest() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.advance(b.clock.Now())
+	b.buckets[b.head].requests++
+	b.stats.Requests++
+}
+
+// TryRetry takes a retry out of the budget, false when there's none left.
+// WithBackoff asks before every retry.
+func (b *Budget) TryRetry() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.advance(b.clock.Now())
+
+	requests, retries := b.window()
+	allowed := b.config.Ratio*float64(requests) + b.config.MinRetriesPerSecond*b.config.Window.Seconds()
+	if float64(retries)+1 > allowed {
+		b.stats.Denied++
+		return false
+	}
+	b.buckets[b.head].retries++
+	b.stats.Granted++
+	return true
+}
+
+// Stats returns the counters, for your dashboards and your postmortems
+func (b *Budget) Stats() BudgetStats {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.advance(b.clock.Now())
+	stats := b.stats
+	stats.WindowRequests, stats.WindowRetries = b.window()
+	return stats
+}
+
+// advance slides the window up to now, forgetting buckets that fell out.
+// b.mu must be held.
+func (b *Budget) advance(now time.Time) {
+	steps := int(now.Sub(b.headStart) / b.width)
+	if steps <= 0 {
+		return
+	}
+	if steps > len(b.buckets) {
+		steps = len(b.buckets)
+	}
+	for i := 0; i < steps; i++ {
+		b.head = (b.head + 1) % len(b.buckets)
+		b.buckets[b.head] = budgetBucket{}
+	}
+	b.headStart = b.headStart.Add(now.Sub(b.headStart).Truncate(b.width))
+}
+
+// window sums up the buckets, b.mu must be held
+func (b *Budget) window() (requests, retries int) {
+	for _, bucket := range b.buckets {
+		requests += bucket.requests
+		retries += bucket.retries
+	}
+	return requests, retries
+}
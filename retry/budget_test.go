@@ -0,0 +1,111 @@
+package retry_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/theHamdiz/it/clock"
+	"github.com/theHamdiz/it/retry"
+)
+
+func TestBudget_RatioOfRequests(t *testing.T) {
+	b := retry.NewBudget(retry.BudgetConfig{Ratio: 0.1})
+	for i := 0; i < 20; i++ {
+		b.RecordRequest()
+	}
+
+	granted := 0
+	for i := 0; i < 5; i++ {
+		if b.TryRetry() {
+			granted++
+		}
+	}
+	if granted != 2 {
+		t.Errorf("Expected 10%% of 20 requests to be 2 retries, got %d", granted)
+	}
+
+	stats := b.Stats()
+	if stats.Requests != 20 || stats.Granted != 2 || stats.Denied != 3 {
+		t.Errorf("Unexpected stats %+v", stats)
+	}
+	if stats.WindowRequests != 20 || stats.WindowRetries != 2 {
+		t.Errorf("Unexpected window %+v", stats)
+	}
+}
+
+func TestBudget_MinRetriesPerSecond(t *testing.T) {
+	b := retry.NewBudget(retry.BudgetConfig{MinRetriesPerSecond: 0.5, Window: 4 * time.Second})
+	// 0.5/s over 4s, without a single request
+	if !b.TryRetry() || !b.TryRetry() || b.TryRetry() {
+		t.Error("Expected exactly 2 retries from the reserve")
+	}
+}
+
+func TestBudget_WindowSlides(t *testing.T) {
+	fake := clock.NewFake(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	b := retry.NewBudget(retry.BudgetConfig{Ratio: 1, Window: 10 * time.Second, Clock: fake})
+	b.RecordRequest()
+	if !b.TryRetry() || b.TryRetry() {
+		t.Fatal("Expected one retry for one request at ratio 1")
+	}
+
+	fake.Advance(9 * time.Second)
+	if stats := b.Stats(); stats.WindowRequests != 1 || stats.WindowRetries != 1 {
+		t.Errorf("Expected the window to still remember recent traffic, got %+v", stats)
+	}
+	fake.Advance(2 * time.Second)
+	if stats := b.Stats(); stats.WindowRequests != 0 || stats.WindowRetries != 0 {
+		t.Errorf("Expected the window to forget old traffic, got %+v", stats)
+	}
+	b.RecordRequest()
+	if !b.TryRetry() {
+		t.Error("Expected a fresh window to allow a retry again")
+	}
+}
+
+func TestWithBackoff_SharedBudget(t *testing.T) {
+	budget := retry.NewBudget(retry.BudgetConfig{Ratio: 0.1})
+	config := retry.Config{Attempts: 3, Backoff: retry.Constant(0), Budget: budget}
+
+	var mu sync.Mutex
+	calls := 0
+	var wg sync.WaitGroup
+	for i := 0; i < 30; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, _ = retry.WithBackoff(context.Background(), config, func(context.Context) (int, error) {
+				mu.Lock()
+				calls++
+				mu.Unlock()
+				return 0, errors.New("outage")
+			})
+		}()
+	}
+	wg.Wait()
+
+	stats := budget.Stats()
+	// Without the budget: 90 calls. With it: 30 first attempts plus at most 10%.
+	if calls != 30+int(stats.Granted) || stats.Granted > 3 {
+		t.Errorf("Expected retries capped at 10%% of 30 requests, got %d calls and %+v", calls, stats)
+	}
+	if stats.Denied == 0 {
+		t.Error("Expected some retries to be denied")
+	}
+}
+
+func TestWithBackoff_BudgetExhausted(t *testing.T) {
+	budget := retry.NewBudget(retry.BudgetConfig{})
+	_, err := retry.WithBackoff(context.Background(), retry.Config{Attempts: 3, Backoff: retry.Constant(0), Budget: budget},
+		func(context.Context) (int, error) {
+			return 0, errors.New("outage")
+		})
+
+	var retryErr *retry.RetryError
+	if !errors.As(err, &retryErr) || retryErr.Limit != retry.ErrBudgetExhausted || retryErr.Attempts != 1 {
+		t.Errorf("Expected the budget to stop things after one attempt, got %v", err)
+	}
+}
@@ -0,0 +1,201 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// PermanentError marks an error no amount of retrying will fix
+type PermanentError struct {
+	Err error
+}
+
+// RetryAfterError carries how long the other side asked us to back off
+type RetryAfterError struct {
+	Err   error
+	Delay time.Duration
+}
+
+// StatusError is an HTTP response that didn't go well, see CheckResponse
+type StatusError struct {
+	StatusCode int
+	Status     string
+}
+
+// Permanent wraps err so WithBackoff gives up on the spot. What comes back
+// is a *RetryError with Limit ErrNotRetryable, holding err and the attempts
+// made; errors.Is and errors.As still find err in it. Validation errors,
+// 404s, "you're not allowed": all permanent.
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &PermanentError{Err: err}
+}
+
+// After wraps err with the wait the server asked for, usually a Retry-After
+// header. WithBackoff waits at least d before the next attempt, longer if
+// the backoff says so.
+func After(err error, d time.Duration) error {
+	if err == nil {
+		return nil
+	}
+	return &RetryAfterError{Err: err, Delay: d}
+}
+
+// IsPermanent reports whether err, or anything it wraps, was marked Permanent
+func IsPermanent(err error) bool {
+	var permanent *PermanentError
+	return errors.As(err, &permanent)
+}
+
+// RetryAfter returns the wait requested with After, if any
+func RetryAfter(err error) (time.Duration, bool) {
+	var after *RetryAfterError
+	if errors.As(err, &after) {
+		return after.Delay, true
+	}
+	return 0, false
+}
+
+// IsRetryable is a RetryIf for talking to things over the network. It says
+// yes to timeouts, connections reset, refused or cut short, temporary DNS
+// failures, errors wrapped with After, and HTTP 408, 429, 502, 503 and 504.
+// Everything else, including cancellation, is assumed to fail again.
+func IsRetryable(err error) bool {
+	if err == nil || IsPermanent(err) || errors.Is(err, context.Canceled) {
+		return false
+	}
+	if _, ok := RetryAfter(err); ok {
+		return true
+	}
+
+	var status *StatusError
+	if errors.As(err, &status) {
+		return RetryableStatus(status.StatusCode)
+	}
+	return IsTransientNetError(err)
+}
+
+// IsTransientNetError reports whether err looks like the network having a
+// bad moment rather than a bad request
+func IsTransientNetError(err error) bool {
+	if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return true
+	}
+	for _, errno := range []syscall.Errno{
+		syscall.ECONNRESET, syscall.ECONNREFUSED, syscall.ECONNABORTED,
+		syscall.EPIPE, syscall.ETIMEDOUT,
+	} {
+		if errors.Is(err, errno) {
+			return true
+		}
+	}
+
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) {
+		return dnsErr.IsTimeout || dnsErr.IsTemporary
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
+
+// RetryableStatus reports whether an HTTP status is worth another try
+func RetryableStatus(code int) bool {
+	switch code {
+	case http.StatusRequestTimeout,
+		http.StatusTooManyRequests,
+		http.StatusBadGateway,
+		http.StatusServiceUnavailable,
+		http.StatusGatewayTimeout:
+		return true
+	default:
+		return false
+	}
+}
+
+// CheckResponse turns 4xx and 5xx responses into a *StatusError, wrapped
+// with After when the server sent a Retry-After header. Anything else is nil.
+func CheckResponse(resp *http.Response) error {
+	if resp.StatusCode < 400 {
+		return nil
+	}
+	err := error(&StatusError{StatusCode: resp.StatusCode, Status: resp.Status})
+	if d, ok := ParseRetryAfter(resp.Header.Get("Retry-After"), time.Now()); ok {
+		return After(err, d)
+	}
+	return err
+}
+
+// ParseRetryAfter reads a Retry-After header, delay-seconds or HTTP-date.
+// Dates in the past mean no wait at all.
+func ParseRetryAfter(header string, now time.Time) (time.Duration, bool) {
+	header = strings.TrimSpace(header)
+	if header == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.ParseInt(header, 10, 64); err == nil {
+		if seconds < 0 {
+			return 0, false
+		}
+		return capDelay(float64(seconds)*float64(time.Second), 0), true
+	}
+	if at, err := http.ParseTime(header); err == nil {
+		if d := at.Sub(now); d > 0 {
+			return d, true
+		}
+		return 0, true
+	}
+	return 0, false
+}
+
+func (e *PermanentError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *PermanentError) Unwrap() error {
+	return e.Err
+}
+
+func (e *RetryAfterError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *RetryAfterError) Unwrap() error {
+	return e.Err
+}
+
+func (e *StatusError) Error() string {
+	if e.Status != "" {
+		return "unexpected HTTP status " + e.Status
+	}
+	return fmt.Sprintf("unexpected HTTP status %d", e.StatusCode)
+}
+
+// shouldRetry applies RetryIf, or the default of retrying everything but
+// permanent errors and the operation's own cancellation
+func (c Config) shouldRetry(err error) bool {
+	if IsPermanent(err) {
+		return false
+	}
+	if c.RetryIf != nil {
+		return c.RetryIf(err)
+	}
+	return !errors.Is(err, context.Canceled)
+}
+
+// unwrapPermanent takes the Permanent wrapper off err, if err is one
+func unwrapPermanent(err error) error {
+	if permanent, ok := err.(*PermanentError); ok {
+		return permanent.Err
+	}
+	return err
+}
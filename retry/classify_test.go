@@ -0,0 +1,186 @@
+package retry_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/theHamdiz/it/retry"
+)
+
+var errValidation = errors.New("name is required")
+
+func TestWithBackoff_PermanentStopsImmediately(t *testing.T) {
+	attempts := 0
+	_, err := retry.WithBackoff(context.Background(), retry.Config{Attempts: 5, Backoff: retry.Constant(time.Millisecond)},
+		func(context.Context) (int, error) {
+			attempts++
+			return 0, retry.Permanent(errValidation)
+		})
+
+	if attempts != 1 {
+		t.Errorf("Expected 1 attempt, got %d", attempts)
+	}
+	var retryErr *retry.RetryError
+	if !errors.As(err, &retryErr) || retryErr.Err != errValidation || !errors.Is(err, retry.ErrNotRetryable) {
+		t.Errorf("Expected ErrNotRetryable around the unwrapped error, got %#v", err)
+	}
+}
+
+func TestWithBackoff_RetryIf(t *testing.T) {
+	attempts := 0
+	config := retry.Config{
+		Attempts: 5,
+		Backoff:  retry.Constant(time.Millisecond),
+		RetryIf:  func(err error) bool { return !errors.Is(err, errValidation) },
+	}
+	_, err := retry.WithBackoff(context.Background(), config, func(context.Context) (int, error) {
+		attempts++
+		if attempts < 3 {
+			return 0, errors.New("flaky")
+		}
+		return 0, fmt.Errorf("saving: %w", errValidation)
+	})
+
+	if attempts != 3 {
+		t.Errorf("Expected to stop at the third attempt, got %d", attempts)
+	}
+	if !errors.Is(err, errValidation) || !errors.Is(err, retry.ErrNotRetryable) {
+		t.Errorf("Expected the validation error, got %v", err)
+	}
+	// Stopping early still keeps the whole story
+	var retryErr *retry.RetryError
+	if !errors.As(err, &retryErr) || retryErr.Attempts != 3 || len(retryErr.History) != 3 {
+		t.Fatalf("Expected a RetryError with 3 attempts on record, got %#v", err)
+	}
+	if retryErr.History[0].Err.Error() != "flaky" {
+		t.Errorf("Expected the first attempt's error in the history, got %v", retryErr.History[0].Err)
+	}
+}
+
+func TestWithBackoff_DoesNotRetryOwnCancellation(t *testing.T) {
+	attempts := 0
+	_, err := retry.WithBackoff(context.Background(), retry.Config{Attempts: 3, Backoff: retry.Constant(time.Millisecond)},
+		func(context.Context) (int, error) {
+			attempts++
+			return 0, fmt.Errorf("query: %w", context.Canceled)
+		})
+
+	if attempts != 1 || !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected a single cancelled attempt, got %d attempts and %v", attempts, err)
+	}
+}
+
+func TestWithBackoff_HonorsRetryAfter(t *testing.T) {
+	attempts := 0
+	start := time.Now()
+	_, err := retry.WithBackoff(context.Background(), retry.Config{Attempts: 2, Backoff: retry.Constant(time.Millisecond)},
+		func(context.Context) (int, error) {
+			attempts++
+			if attempts == 1 {
+				return 0, retry.After(errors.New("slow down"), 50*time.Millisecond)
+			}
+			return 1, nil
+		})
+
+	if err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < 50*time.Millisecond {
+		t.Errorf("Expected to wait at least the requested 50ms, waited %v", elapsed)
+	}
+}
+
+func TestIsRetryable(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"nil", nil, false},
+		{"plain", errors.New("bad input"), false},
+		{"permanent", retry.Permanent(syscall.ECONNRESET), false},
+		{"canceled", context.Canceled, false},
+		{"deadline", context.DeadlineExceeded, true},
+		{"reset", &net.OpError{Op: "read", Err: os.NewSyscallError("read", syscall.ECONNRESET)}, true},
+		{"refused", fmt.Errorf("dial: %w", syscall.ECONNREFUSED), true},
+		{"unexpected EOF", io.ErrUnexpectedEOF, true},
+		{"dns timeout", &net.DNSError{Err: "timeout", IsTimeout: true}, true},
+		{"dns not found", &net.DNSError{Err: "no such host", IsNotFound: true}, false},
+		{"429", &retry.StatusError{StatusCode: http.StatusTooManyRequests}, true},
+		{"503", fmt.Errorf("calling api: %w", &retry.StatusError{StatusCode: http.StatusServiceUnavailable}), true},
+		{"404", &retry.StatusError{StatusCode: http.StatusNotFound}, false},
+		{"after", retry.After(errors.New("busy"), time.Second), true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := retry.IsRetryable(tt.err); got != tt.want {
+				t.Errorf("IsRetryable(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsRetryable_ClientTimeout(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	}))
+	defer srv.Close()
+
+	client := &http.Client{Timeout: 20 * time.Millisecond}
+	_, err := client.Get(srv.URL)
+	if err == nil {
+		t.Fatal("Expected the request to time out")
+	}
+	if !retry.IsRetryable(err) {
+		t.Errorf("Expected a client timeout to be retryable: %v", err)
+	}
+}
+
+func TestCheckResponse(t *testing.T) {
+	resp := &http.Response{StatusCode: http.StatusTooManyRequests, Status: "429 Too Many Requests", Header: http.Header{}}
+	resp.Header.Set("Retry-After", "7")
+
+	err := retry.CheckResponse(resp)
+	if d, ok := retry.RetryAfter(err); !ok || d != 7*time.Second {
+		t.Errorf("Expected a 7s Retry-After, got %v, %v", d, ok)
+	}
+	var status *retry.StatusError
+	if !errors.As(err, &status) || status.StatusCode != http.StatusTooManyRequests {
+		t.Errorf("Expected a *StatusError inside, got %v", err)
+	}
+	if err := retry.CheckResponse(&http.Response{StatusCode: http.StatusOK}); err != nil {
+		t.Errorf("Expected nil for 200, got %v", err)
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		header string
+		want   time.Duration
+		ok     bool
+	}{
+		{"120", 2 * time.Minute, true},
+		{now.Add(30 * time.Second).Format(http.TimeFormat), 30 * time.Second, true},
+		{now.Add(-time.Minute).Format(http.TimeFormat), 0, true},
+		{"", 0, false},
+		{"-1", 0, false},
+		{"soon", 0, false},
+	}
+	for _, tt := range tests {
+		d, ok := retry.ParseRetryAfter(tt.header, now)
+		if d != tt.want || ok != tt.ok {
+			t.Errorf("ParseRetryAfter(%q) = %v, %v; want %v, %v", tt.header, d, ok, tt.want, tt.ok)
+		}
+	}
+}
@@ -0,0 +1,81 @@
+package retry
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Attempt is one call to the operation that didn't work out
+type Attempt struct {
+	// 1 for the first call, 2 for the first retry...
+	Number int
+	Start  time.Time
+	// How long the call took
+	Duration time.Duration
+	// How long we waited before making it
+	Delay time.Duration
+	Err   error
+}
+
+// RetryError is what WithBackoff returns when it gives up, whether a limit
+// ended the loop or the error wasn't worth retrying. errors.Is works on both
+// the limit and the last attempt's error, so errors.Is(err, context.DeadlineExceeded) and
+// errors.Is(err, io.EOF) keep doing what you'd hope.
+type RetryError struct {
+	// ErrAttemptsExhausted, ErrMaxElapsedTime, ErrBudgetExhausted,
+	// ErrNotRetryable, or the error of the context
+	Limit error
+	// How many times the operation was called
+	Attempts int
+	// Time from the first attempt until giving up
+	Elapsed time.Duration
+	// The last attempt's error, nil if ctx was done before the first one
+	Err error
+	// Every failed attempt, oldest first
+	History []Attempt
+}
+
+var (
+	// ErrAttemptsExhausted means every one of Config.Attempts failed
+	ErrAttemptsExhausted = errors.New("retry: attempts exhausted")
+	// ErrMaxElapsedTime means Config.MaxElapsedTime ran out
+	ErrMaxElapsedTime = errors.New("retry: max elapsed time exceeded")
+	// ErrNotRetryable means RetryIf turned the error down, or it was Permanent
+	ErrNotRetryable = errors.New("retry: error not retryable")
+)
+
+func (e *RetryError) Error() string {
+	if e.Err == nil {
+		return fmt.Sprintf("%v after %d attempts", e.Limit, e.Attempts)
+	}
+	return fmt.Sprintf("%v after %d attempts: %v", e.Limit, e.Attempts, e.Err)
+}
+
+func (e *RetryError) Unwrap() []error {
+	if e.Err == nil {
+		return []error{e.Limit}
+	}
+	return []error{e.Limit, e.Err}
+}
+
+// Errors returns the error of every attempt, oldest first
+func (e *RetryError) Errors() []error {
+	errs := make([]error, len(e.History))
+	for i, a := range e.History {
+		errs[i] = a.Err
+	}
+	return errs
+}
+
+// Detail is Error plus a line per attempt, for when one line isn't the whole story
+func (e *RetryError) Detail() string {
+	var b strings.Builder
+	b.WriteString(e.Error())
+	for _, a := range e.History {
+		fmt.Fprintf(&b, "\n  attempt %d at %s (waited %v, took %v): %v",
+			a.Number, a.Start.Format("15:04:05.000"), a.Delay, a.Duration, a.Err)
+	}
+	return b.String()
+}
@@ -0,0 +1,119 @@
+package retry_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/theHamdiz/it/clock"
+	"github.com/theHamdiz/it/retry"
+)
+
+func TestWithBackoff_CancelInterruptsWait(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(20*time.Millisecond, cancel)
+
+	start := time.Now()
+	_, err := retry.WithBackoff(ctx, retry.Config{Attempts: 3, Backoff: retry.Constant(10 * time.Second)},
+		func(context.Context) (int, error) {
+			return 0, errors.New("down")
+		})
+
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Expected cancellation to cut the 10s wait short, took %v", elapsed)
+	}
+	var retryErr *retry.RetryError
+	if !errors.As(err, &retryErr) || !errors.Is(retryErr.Limit, context.Canceled) {
+		t.Fatalf("Expected a RetryError ended by cancellation, got %v", err)
+	}
+	if retryErr.Attempts != 1 || retryErr.Err == nil || retryErr.Err.Error() != "down" {
+		t.Errorf("Expected one attempt ending in 'down', got %+v", retryErr)
+	}
+}
+
+func TestWithBackoff_PerAttemptTimeout(t *testing.T) {
+	attempts := 0
+	config := retry.Config{Attempts: 3, Backoff: retry.Constant(time.Millisecond), PerAttemptTimeout: 10 * time.Millisecond}
+	result, err := retry.WithBackoff(context.Background(), config, func(ctx context.Context) (string, error) {
+		attempts++
+		if attempts < 3 {
+			// Hang until our own deadline passes
+			<-ctx.Done()
+			return "", ctx.Err()
+		}
+		if _, ok := ctx.Deadline(); !ok {
+			t.Error("Expected every attempt to get a deadline")
+		}
+		return "finally", nil
+	})
+
+	if err != nil || result != "finally" {
+		t.Errorf("Expected the third attempt to succeed, got %q, %v", result, err)
+	}
+}
+
+func TestWithBackoff_MaxElapsedTime(t *testing.T) {
+	attempts := 0
+	config := retry.Config{Attempts: 100, Backoff: retry.Constant(20 * time.Millisecond), MaxElapsedTime: 50 * time.Millisecond}
+
+	start := time.Now()
+	_, err := retry.WithBackoff(context.Background(), config, func(context.Context) (int, error) {
+		attempts++
+		return 0, errors.New("down")
+	})
+
+	if !errors.Is(err, retry.ErrMaxElapsedTime) {
+		t.Fatalf("Expected ErrMaxElapsedTime, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 50*time.Millisecond {
+		t.Errorf("Expected to stop before the budget ran out, took %v", elapsed)
+	}
+	if attempts < 2 || attempts > 3 {
+		t.Errorf("Expected 2 or 3 attempts within 50ms, got %d", attempts)
+	}
+}
+
+func TestWithBackoff_AttemptsExhausted(t *testing.T) {
+	cause := errors.New("down")
+	_, err := retry.WithBackoff(context.Background(), retry.Config{Attempts: 2, Backoff: retry.Constant(0)},
+		func(context.Context) (int, error) {
+			return 0, cause
+		})
+
+	if !errors.Is(err, retry.ErrAttemptsExhausted) || !errors.Is(err, cause) {
+		t.Errorf("Expected both the limit and the cause to be visible, got %v", err)
+	}
+	if errors.Is(err, retry.ErrMaxElapsedTime) {
+		t.Error("Expected only the limit that actually hit")
+	}
+}
+
+func TestWithBackoff_FakeClock(t *testing.T) {
+	fake := clock.NewFake(time.Now())
+	config := retry.Config{Attempts: 3, Backoff: retry.Constant(10 * time.Second), Clock: fake}
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := retry.WithBackoff(context.Background(), config, func(context.Context) (int, error) {
+			return 0, errors.New("down")
+		})
+		done <- err
+	}()
+
+	// Two 10s waits, skipped in no time at all
+	for i := 0; i < 2; i++ {
+		fake.BlockUntil(1)
+		fake.Advance(10 * time.Second)
+	}
+
+	select {
+	case err := <-done:
+		var retryErr *retry.RetryError
+		if !errors.As(err, &retryErr) || retryErr.Elapsed != 20*time.Second {
+			t.Errorf("Expected 20s on the fake clock, got %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Expected the retries to finish without real waiting")
+	}
+}
@@ -0,0 +1,111 @@
+package retry_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/theHamdiz/it/logger"
+	"github.com/theHamdiz/it/logger/logtest"
+	"github.com/theHamdiz/it/retry"
+)
+
+func TestWithBackoff_Hooks(t *testing.T) {
+	var retried []int
+	var waits []time.Duration
+	var gaveUp error
+	var history []retry.Attempt
+
+	config := retry.Config{
+		Attempts: 3,
+		Backoff:  retry.Linear(time.Millisecond, time.Millisecond, 0),
+		OnRetry: func(a retry.Attempt, wait time.Duration) {
+			retried = append(retried, a.Number)
+			waits = append(waits, wait)
+		},
+		OnGiveUp: func(err error, h []retry.Attempt) {
+			gaveUp, history = err, h
+		},
+	}
+
+	calls := 0
+	_, err := retry.WithBackoff(context.Background(), config, func(context.Context) (int, error) {
+		calls++
+		return 0, fmt.Errorf("failure %d", calls)
+	})
+
+	if len(retried) != 2 || retried[0] != 1 || retried[1] != 2 {
+		t.Errorf("Expected OnRetry after attempts 1 and 2, got %v", retried)
+	}
+	if len(waits) != 2 || waits[0] != time.Millisecond || waits[1] != 2*time.Millisecond {
+		t.Errorf("Expected the upcoming waits, got %v", waits)
+	}
+	if gaveUp != err || len(history) != 3 {
+		t.Errorf("Expected OnGiveUp with the returned error and 3 attempts, got %v and %d", gaveUp, len(history))
+	}
+}
+
+func TestWithBackoff_NoGiveUpOnSuccess(t *testing.T) {
+	config := retry.Config{
+		Attempts: 3,
+		Backoff:  retry.Constant(0),
+		OnGiveUp: func(error, []retry.Attempt) { t.Error("Expected no OnGiveUp after a success") },
+	}
+	calls := 0
+	_, _ = retry.WithBackoff(context.Background(), config, func(context.Context) (int, error) {
+		calls++
+		if calls == 1 {
+			return 0, errors.New("once")
+		}
+		return 1, nil
+	})
+}
+
+func TestRetryError_History(t *testing.T) {
+	errs := []error{errors.New("first"), errors.New("second"), io.ErrUnexpectedEOF}
+	calls := 0
+	_, err := retry.WithBackoff(context.Background(), retry.Config{Attempts: 3, Backoff: retry.Constant(5 * time.Millisecond)},
+		func(context.Context) (int, error) {
+			calls++
+			return 0, errs[calls-1]
+		})
+
+	var retryErr *retry.RetryError
+	if !errors.As(err, &retryErr) {
+		t.Fatalf("Expected a *RetryError, got %T", err)
+	}
+	if !errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, errs[0]) {
+		t.Error("Expected errors.Is to see the last cause, and only that one")
+	}
+	if len(retryErr.History) != 3 {
+		t.Fatalf("Expected 3 attempts on record, got %d", len(retryErr.History))
+	}
+	for i, a := range retryErr.History {
+		if a.Number != i+1 || a.Err != errs[i] || a.Start.IsZero() {
+			t.Errorf("Unexpected attempt %+v", a)
+		}
+	}
+	if retryErr.History[0].Delay != 0 || retryErr.History[1].Delay != 5*time.Millisecond {
+		t.Errorf("Expected the waits before each attempt, got %v and %v", retryErr.History[0].Delay, retryErr.History[1].Delay)
+	}
+	if !retryErr.History[1].Start.After(retryErr.History[0].Start) {
+		t.Error("Expected attempts in order")
+	}
+	if got := retryErr.Errors(); len(got) != 3 || got[1] != errs[1] {
+		t.Errorf("Expected every error back, got %v", got)
+	}
+}
+
+func TestWithBackoff_Logger(t *testing.T) {
+	l, rec := logtest.New()
+	_, _ = retry.WithBackoff(context.Background(), retry.Config{Attempts: 2, Backoff: retry.Constant(0), Logger: l},
+		func(context.Context) (int, error) {
+			return 0, errors.New("down")
+		})
+
+	rec.AssertCount(t, 2, logger.LevelWarning, "Retry attempt failed", map[string]any{"error": "down"})
+	rec.AssertContains(t, logger.LevelError, "Retry gave up", map[string]any{"attempts": 2})
+}
@@ -4,12 +4,50 @@ import (
 	"context"
 	"math/rand"
 	"time"
+
+	"github.com/theHamdiz/it/clock"
+	"github.com/theHamdiz/it/logger"
 )
 
+// Attempts are logged at DEBUG under "retry", turn it up with logger.SetNamedLevel
+var log = logger.Named("retry")
+
 // Config holds configuration for retry operations because sometimes
 // you need more than just "try again and hope for the best"
 type Config struct {
-	Attempts     int
+	Attempts int
+	// Backoff decides the waits between attempts. Leave it nil and the
+	// fields below decide them, the way they always have.
+	Backoff Backoff
+	// RetryIf says which errors are worth another attempt. Nil retries
+	// everything but Permanent errors and context.Canceled; IsRetryable
+	// is pickier and knows its way around the network.
+	RetryIf func(error) bool
+	// Each call to the operation gets a context that times out after this
+	// long, zero means it gets ctx as is
+	PerAttemptTimeout time.Duration
+	// Stop once this much time went by since the first attempt; a wait
+	// that would end past it isn't started. Zero means no limit.
+	MaxElapsedTime time.Duration
+	// Budget, when shared by many calls, caps how many retries they may
+	// make between them
+	Budget *Budget
+	// OnRetry hears about every failed attempt that gets another go, and
+	// how long the wait until then will be
+	OnRetry func(failed Attempt, wait time.Duration)
+	// OnGiveUp hears about the error WithBackoff is about to return, and
+	// the attempts that led there
+	OnGiveUp func(err error, history []Attempt)
+	// Logger, when set, gets a WARNING per failed attempt and an ERROR when
+	// we give up. Nil keeps it to DEBUG on the "retry" named logger.
+	Logger *logger.Logger
+	// Clock tells the time, nil means the real one
+	Clock clock.Clock
+
+	// The waits when Backoff is nil: InitialDelay first, then each one
+	// Multiplier times the last, capped at MaxDelay, RandomFactor of it
+	// added on top. Unlike ExponentialBackoff, zero means zero: with no
+	// MaxDelay or no Multiplier every wait after the first is zero.
 	InitialDelay time.Duration
 	MaxDelay     time.Duration
 	Multiplier   float64
@@ -28,39 +66,160 @@ func DefaultRetryConfig() Config {
 	}
 }
 
-// WithBackoff retries an operation with exponential backoff because
-// hammering a service repeatedly is so last decade
+// WithBackoff retries an operation, waiting between attempts as
+// config.Backoff says, because hammering a service repeatedly is so last decade.
+// Whatever ends it, the error is a *RetryError saying why, with every attempt
+// on record: a limit (attempts, MaxElapsedTime, the budget or ctx), or
+// ErrNotRetryable for errors RetryIf turns down and Permanent ones, whose
+// cause comes unwrapped.
 func WithBackoff[T any](ctx context.Context, config Config, operation func(context.Context) (T, error)) (T, error) {
-	var result T
-	var lastError error
-	delay := config.InitialDelay
-
-	for attempt := 0; attempt < config.Attempts; attempt++ {
-		select {
-		case <-ctx.Done():
-			return result, ctx.Err()
-		default:
-			if attempt > 0 {
-				// Ensure jitter only adds to the delay (never reduces it)
-				jitter := time.Duration(rand.Float64() * float64(delay) * config.RandomFactor)
-				actualDelay := delay + jitter
-
-				time.Sleep(actualDelay)
-
-				// Ensure delay accumulates correctly with max cap
-				delay = time.Duration(float64(delay) * config.Multiplier)
-				if delay > config.MaxDelay {
-					delay = config.MaxDelay
-				}
-			}
-
-			result, err := operation(ctx)
-			if err == nil {
-				return result, nil
-			}
-			lastError = err
+	var zero T
+	var history []Attempt
+	var wait time.Duration
+	backoff := config.backoff()
+	clk := clock.Or(config.Clock)
+	start := clk.Now()
+	if config.Budget != nil {
+		config.Budget.RecordRequest()
+	}
+
+	giveUp := func(err error) (T, error) {
+		config.gaveUp(err, history, clk.Since(start))
+		return zero, err
+	}
+	stopWith := func(limit, cause error) (T, error) {
+		return giveUp(&RetryError{Limit: limit, Attempts: len(history), Elapsed: clk.Since(start), Err: cause, History: history})
+	}
+	stop := func(limit error) (T, error) {
+		if len(history) == 0 {
+			return stopWith(limit, nil)
+		}
+		return stopWith(limit, history[len(history)-1].Err)
+	}
+
+	for number := 1; number <= config.Attempts; number++ {
+		if ctx.Err() != nil {
+			return stop(ctx.Err())
+		}
+		if err := sleep(ctx, clk, wait); err != nil {
+			return stop(err)
+		}
+
+		attempt := Attempt{Number: number, Start: clk.Now(), Delay: wait}
+		result, err := callOnce(ctx, clk, config.PerAttemptTimeout, operation)
+		if err == nil {
+			return result, nil
+		}
+		attempt.Duration, attempt.Err = clk.Since(attempt.Start), err
+		history = append(history, attempt)
+
+		if ctx.Err() != nil {
+			config.failed(attempt, 0)
+			return stop(ctx.Err())
+		}
+		if !config.shouldRetry(err) {
+			config.failed(attempt, 0)
+			return stopWith(ErrNotRetryable, unwrapPermanent(err))
+		}
+		if number == config.Attempts {
+			config.failed(attempt, 0)
+			break
+		}
+
+		wait = backoff.Next(number, wait)
+		// The server knows its own load better than our backoff does
+		if requested, ok := RetryAfter(err); ok && requested > wait {
+			wait = requested
+		}
+		// No point sleeping just to find out we're out of time
+		if config.MaxElapsedTime > 0 && clk.Since(start)+wait > config.MaxElapsedTime {
+			config.failed(attempt, 0)
+			return stop(ErrMaxElapsedTime)
+		}
+		// Asked last, so the budget only pays for retries that will happen
+		if config.Budget != nil && !config.Budget.TryRetry() {
+			config.failed(attempt, 0)
+			return stop(ErrBudgetExhausted)
+		}
+		config.failed(attempt, wait)
+		if config.OnRetry != nil {
+			config.OnRetry(attempt, wait)
 		}
 	}
 
-	return result, lastError
+	if len(history) == 0 {
+		return zero, nil
+	}
+	return stop(ErrAttemptsExhausted)
+}
+
+// backoff returns the configured Backoff, or builds one from the legacy fields
+func (c Config) backoff() Backoff {
+	if c.Backoff != nil {
+		return c.Backoff
+	}
+	return BackoffFunc(func(retry int, _ time.Duration) time.Duration {
+		delay := float64(c.InitialDelay)
+		for i := 1; i < retry && delay > 0; i++ {
+			delay = min(delay*c.Multiplier, float64(c.MaxDelay))
+		}
+		return capDelay(delay+rand.Float64()*delay*c.RandomFactor, 0)
+	})
+}
+
+// callOnce calls operation once, on its own deadline if PerAttemptTimeout says so
+func callOnce[T any](ctx context.Context, clk clock.Clock, timeout time.Duration, operation func(context.Context) (T, error)) (T, error) {
+	if timeout <= 0 {
+		return operation(ctx)
+	}
+	attemptCtx, cancel := clock.WithTimeout(ctx, clk, timeout)
+	defer cancel()
+	return operation(attemptCtx)
+}
+
+// sleep waits for d, or less if ctx is done first
+func sleep(ctx context.Context, clk clock.Clock, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	timer := clk.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C():
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// failed logs a failed attempt, next is the wait before the next one, zero if none
+func (c Config) failed(a Attempt, next time.Duration) {
+	if c.Logger == nil {
+		log.Debugf("Attempt %d/%d failed: %v", a.Number, c.Attempts, a.Err)
+		return
+	}
+	data := map[string]any{
+		"attempt":  a.Number,
+		"attempts": c.Attempts,
+		"error":    a.Err.Error(),
+		"duration": a.Duration.String(),
+	}
+	if next > 0 {
+		data["next_delay"] = next.String()
+	}
+	c.Logger.StructuredLog(logger.LevelWarning, "Retry attempt failed", data)
+}
+
+// gaveUp tells OnGiveUp and the logger that we're done trying
+func (c Config) gaveUp(err error, history []Attempt, elapsed time.Duration) {
+	if c.Logger != nil {
+		c.Logger.StructuredError("Retry gave up", map[string]any{
+			"attempts": len(history),
+			"elapsed":  elapsed.String(),
+			"error":    err.Error(),
+		})
+	}
+	if c.OnGiveUp != nil {
+		c.OnGiveUp(err, history)
+	}
 }
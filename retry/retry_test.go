@@ -6,6 +6,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/theHamdiz/it/clock"
 	"github.com/theHamdiz/it/retry"
 )
 
@@ -204,3 +205,49 @@ func TestRetryWithBackoff_Jitter(t *testing.T) {
 		)
 	}
 }
+
+// TestRetryWithBackoff_LegacyZeroValues pins the waits the Config fields
+// have always made, zero MaxDelay and Multiplier included.
+func TestRetryWithBackoff_LegacyZeroValues(t *testing.T) {
+	tests := []struct {
+		name   string
+		config retry.Config
+		waits  []time.Duration
+	}{
+		{"zero max and multiplier", retry.Config{Attempts: 4, InitialDelay: time.Second}, []time.Duration{time.Second, 0, 0}},
+		{"zero max", retry.Config{Attempts: 4, InitialDelay: time.Second, Multiplier: 2}, []time.Duration{time.Second, 0, 0}},
+		{"capped", retry.Config{Attempts: 5, InitialDelay: time.Second, MaxDelay: 3 * time.Second, Multiplier: 2}, []time.Duration{time.Second, 2 * time.Second, 3 * time.Second, 3 * time.Second}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := clock.NewFake(time.Now())
+			config := tt.config
+			config.Clock = fake
+
+			done := make(chan error, 1)
+			go func() {
+				_, err := retry.WithBackoff(context.Background(), config, func(context.Context) (int, error) {
+					return 0, errors.New("down")
+				})
+				done <- err
+			}()
+			for _, wait := range tt.waits {
+				if wait > 0 {
+					fake.BlockUntil(1)
+					fake.Advance(wait)
+				}
+			}
+
+			var retryErr *retry.RetryError
+			if err := <-done; !errors.As(err, &retryErr) {
+				t.Fatalf("Expected a RetryError, got %v", err)
+			}
+			for i, wait := range tt.waits {
+				if got := retryErr.History[i+1].Delay; got != wait {
+					t.Errorf("Expected wait %d to be %v, got %v", i+1, wait, got)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,360 @@
+package rl
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/theHamdiz/it/clock"
+	"github.com/theHamdiz/it/retry"
+)
+
+// Transport is an http.RoundTripper with manners: it waits on a Limiter
+// before every request, and when upstream answers 429 anyway it backs off
+// for as long as Retry-After says, every request through it, not just the
+// one that got told off.
+type Transport struct {
+	// Base does the actual round trip, http.DefaultTransport if nil
+	Base http.RoundTripper
+	// Limiter is waited on before each request goes out, nil waits on nothing
+	Limiter Limiter
+	// MaxRetries is how many times a request answered with 429 is sent
+	// again. Requests whose body can't be replayed are never sent again.
+	MaxRetries int
+	// MaxWait caps the Retry-After we'll sit out; a longer one hands the 429
+	// straight back. Zero means a minute.
+	MaxWait time.Duration
+
+	clock clock.Clock
+
+	mu sync.Mutex
+	// upstream asked us to keep quiet until then
+	pausedUntil time.Time
+}
+
+// KeyLimiter says whether a request with the given key may go.
+// *KeyedLimiter[string] is one, PerKey turns any Limiter into one.
+type KeyLimiter interface {
+	Allow(key string) bool
+}
+
+// PerKey hands each key the Limiter it returns for it, so windows, buckets
+// and anything else behind Limiter can guard a Middleware. Return the same
+// one for every key and all requests share it.
+type PerKey func(key string) Limiter
+
+// limitStatus is where a limiter stands, as the X-RateLimit headers tell it
+type limitStatus struct {
+	limit     int
+	remaining int
+	// until the limiter has all its room back
+	reset time.Duration
+	// until a turned down request would get through, negative if never
+	retryAfter time.Duration
+}
+
+// statusLimiter is a Limiter that can tell where it stands right as it
+// decides, in one go so nobody sneaks in between
+type statusLimiter interface {
+	allowWithStatus() (bool, limitStatus)
+}
+
+const (
+	defaultTransportRetries = 3
+	defaultTransportMaxWait = time.Minute
+	// how long to back off from a 429 that didn't say
+	defaultRetryAfter = time.Second
+)
+
+// errNotRewindable means a request's body was read and can't be had again
+var errNotRewindable = errors.New("rl: request body can't be sent again")
+
+// Middleware takes these, with their headers
+var (
+	_ KeyLimiter    = (*KeyedLimiter[string])(nil)
+	_ KeyLimiter    = PerKey(nil)
+	_ statusLimiter = (*TokenBucket)(nil)
+	_ statusLimiter = (*FixedWindow)(nil)
+	_ statusLimiter = (*SlidingWindowLog)(nil)
+	_ statusLimiter = (*SlidingWindowCounter)(nil)
+)
+
+// Middleware limits requests per key, the key being whatever key says it
+// is, RemoteIP if nil. When the limiter behind a key is one of ours every
+// response carries X-RateLimit-Limit, X-RateLimit-Remaining and
+// X-RateLimit-Reset (seconds until it has all its room back), and the
+// ones that don't get through are a 429 with Retry-After. Any other
+// limiter still gets its 429s, just without the headers.
+func Middleware(limiter KeyLimiter, key func(*http.Request) string) func(http.Handler) http.Handler {
+	if key == nil {
+		key = RemoteIP
+	}
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			allowed, status, known := allowWithStatus(limiter, key(r))
+
+			h := w.Header()
+			if known {
+				h.Set("X-RateLimit-Limit", strconv.Itoa(status.limit))
+				h.Set("X-RateLimit-Remaining", strconv.Itoa(status.remaining))
+				h.Set("X-RateLimit-Reset", seconds(status.reset))
+			}
+			if allowed {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			if known && status.retryAfter >= 0 {
+				h.Set("Retry-After", seconds(max(status.retryAfter, time.Second)))
+			}
+			http.Error(w, http.StatusText(http.StatusTooManyRequests), http.StatusTooManyRequests)
+		})
+	}
+}
+
+// Allow asks key's Limiter
+func (f PerKey) Allow(key string) bool {
+	return f(key).Allow()
+}
+
+// RemoteIP keys requests by the address they came from. Behind a proxy
+// that's the proxy, so bring your own key func that trusts the right header.
+func RemoteIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// NewTransport wraps base so requests wait on limiter first, and retries
+// 429s up to 3 times
+func NewTransport(base http.RoundTripper, limiter Limiter, opts ...RateLimiterOption) *Transport {
+	return &Transport{
+		Base:       base,
+		Limiter:    limiter,
+		MaxRetries: defaultTransportRetries,
+		clock:      applyOptions(opts).clock,
+	}
+}
+
+// RoundTrip sends req once the limiter and upstream are both fine with it
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	clk := clock.Or(t.clock)
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	maxWait := t.MaxWait
+	if maxWait <= 0 {
+		maxWait = defaultTransportMaxWait
+	}
+
+	for attempt := 0; ; attempt++ {
+		if err := t.waitOutPause(ctx, clk); err != nil {
+			closeBody(req)
+			return nil, err
+		}
+		if t.Limiter != nil {
+			if err := t.Limiter.Wait(ctx); err != nil {
+				closeBody(req)
+				return nil, err
+			}
+		}
+
+		resp, err := base.RoundTrip(req)
+		if err != nil || resp.StatusCode != http.StatusTooManyRequests {
+			return resp, err
+		}
+
+		wait, ok := retry.ParseRetryAfter(resp.Header.Get("Retry-After"), clk.Now())
+		if !ok {
+			wait = defaultRetryAfter
+		}
+		if wait > maxWait {
+			return resp, nil
+		}
+		t.pause(clk.Now().Add(wait))
+
+		if attempt >= t.MaxRetries {
+			return resp, nil
+		}
+		again, err := rewind(req)
+		if err != nil {
+			return resp, nil
+		}
+		_, _ = io.Copy(io.Discard, resp.Body)
+		_ = resp.Body.Close()
+		req = again
+	}
+}
+
+// allowWithStatus asks limiter about key and, if it's one of ours, where
+// it stands now
+func allowWithStatus(limiter KeyLimiter, key string) (bool, limitStatus, bool) {
+	switch l := limiter.(type) {
+	case *KeyedLimiter[string]:
+		allowed, status := l.allowWithStatus(key)
+		return allowed, status, true
+	case PerKey:
+		keyed := l(key)
+		if sl, ok := keyed.(statusLimiter); ok {
+			allowed, status := sl.allowWithStatus()
+			return allowed, status, true
+		}
+		return keyed.Allow(), limitStatus{}, false
+	}
+	return limiter.Allow(key), limitStatus{}, false
+}
+
+// allowWithStatus is Allow plus where the bucket stands after it
+func (tb *TokenBucket) allowWithStatus() (bool, limitStatus) {
+	tb.mu.Lock()
+	defer tb.mu.Unlock()
+	allowed := tb.allowN(1)
+
+	s := limitStatus{limit: tb.burst, remaining: max(int(math.Floor(tb.tokens)), 0)}
+	switch {
+	case tb.limit == Inf:
+		s.remaining = tb.burst
+	case tb.limit <= 0:
+		if tb.tokens < 1 {
+			s.retryAfter = -1
+		}
+	default:
+		s.reset = tokensToDuration(float64(tb.burst)-tb.tokens, tb.limit)
+		if tb.tokens < 1 {
+			s.retryAfter = tokensToDuration(1-tb.tokens, tb.limit)
+		}
+	}
+	return allowed, s
+}
+
+// allowWithStatus is Allow plus where the window stands after it
+func (fw *FixedWindow) allowWithStatus() (bool, limitStatus) {
+	fw.mu.Lock()
+	defer fw.mu.Unlock()
+	now := fw.clock.Now()
+	allowed, wait := fw.takeAt(now)
+
+	s := limitStatus{limit: max(fw.limit, 0), remaining: max(fw.limit-fw.count, 0), retryAfter: retryAfter(allowed, wait)}
+	if fw.count > 0 {
+		s.reset = fw.start.Add(fw.window).Sub(now)
+	}
+	return allowed, s
+}
+
+// allowWithStatus is Allow plus where the log stands after it
+func (sl *SlidingWindowLog) allowWithStatus() (bool, limitStatus) {
+	sl.mu.Lock()
+	defer sl.mu.Unlock()
+	now := sl.clock.Now()
+	allowed, wait := sl.takeAt(now)
+
+	s := limitStatus{limit: max(sl.limit, 0), remaining: max(sl.limit-sl.count, 0), retryAfter: retryAfter(allowed, wait)}
+	if sl.count > 0 {
+		// the newest event is the last one to slide out
+		s.reset = sl.log[(sl.head+sl.count-1)%sl.limit].Add(sl.window).Sub(now)
+	}
+	return allowed, s
+}
+
+// allowWithStatus is Allow plus where the estimate stands after it
+func (sc *SlidingWindowCounter) allowWithStatus() (bool, limitStatus) {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	now := sc.clock.Now()
+	allowed, wait := sc.takeAt(now)
+
+	elapsed := now.Sub(sc.start)
+	estimate := float64(sc.previous)*(1-float64(elapsed)/float64(sc.window)) + float64(sc.current)
+	s := limitStatus{
+		limit:      max(sc.limit, 0),
+		remaining:  max(int(math.Floor(float64(sc.limit)-estimate)), 0),
+		retryAfter: retryAfter(allowed, wait),
+	}
+	switch {
+	case sc.current > 0:
+		s.reset = 2*sc.window - elapsed
+	case sc.previous > 0:
+		s.reset = sc.window - elapsed
+	}
+	return allowed, s
+}
+
+// retryAfter turns what take said into a limitStatus retryAfter
+func retryAfter(allowed bool, wait time.Duration) time.Duration {
+	switch {
+	case allowed:
+		return 0
+	case wait <= 0:
+		return -1
+	}
+	return wait
+}
+
+// seconds formats d as whole seconds, rounded up
+func seconds(d time.Duration) string {
+	return strconv.FormatInt(int64(math.Ceil(d.Seconds())), 10)
+}
+
+// pause makes every request hold off until then, later pauses win
+func (t *Transport) pause(until time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if until.After(t.pausedUntil) {
+		t.pausedUntil = until
+	}
+}
+
+// waitOutPause sleeps through whatever pause upstream asked for
+func (t *Transport) waitOutPause(ctx context.Context, clk clock.Clock) error {
+	for {
+		t.mu.Lock()
+		wait := clk.Until(t.pausedUntil)
+		t.mu.Unlock()
+		if wait <= 0 {
+			return ctx.Err()
+		}
+
+		timer := clk.NewTimer(wait)
+		select {
+		case <-timer.C():
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		}
+	}
+}
+
+// closeBody closes req's body, RoundTrip owes the caller that even when
+// the request never goes out
+func closeBody(req *http.Request) {
+	if req.Body != nil {
+		_ = req.Body.Close()
+	}
+}
+
+// rewind makes a fresh copy of req to send again, if its body allows that
+func rewind(req *http.Request) (*http.Request, error) {
+	again := req.Clone(req.Context())
+	if req.Body == nil || req.Body == http.NoBody {
+		return again, nil
+	}
+	if req.GetBody == nil {
+		return nil, errNotRewindable
+	}
+	body, err := req.GetBody()
+	if err != nil {
+		return nil, err
+	}
+	again.Body = body
+	return again, nil
+}
@@ -0,0 +1,312 @@
+package rl_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/theHamdiz/it/clock"
+	"github.com/theHamdiz/it/rl"
+)
+
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(r *http.Request) (*http.Response, error) {
+	return f(r)
+}
+
+func tooManyRequests(retryAfter string) *http.Response {
+	resp := &http.Response{
+		StatusCode: http.StatusTooManyRequests,
+		Header:     http.Header{},
+		Body:       io.NopCloser(strings.NewReader("slow down")),
+	}
+	if retryAfter != "" {
+		resp.Header.Set("Retry-After", retryAfter)
+	}
+	return resp
+}
+
+func okResponse() *http.Response {
+	return &http.Response{StatusCode: http.StatusOK, Header: http.Header{}, Body: http.NoBody}
+}
+
+func TestMiddleware(t *testing.T) {
+	fake := fakeClock(t)
+	kl := rl.NewKeyedLimiter[string](rl.KeyedConfig{Rate: 0.5, Burst: 2}, rl.WithClock(fake))
+	handler := rl.Middleware(kl, nil)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	serve := func(remoteAddr string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.RemoteAddr = remoteAddr
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	first := serve("10.0.0.1:1234")
+	if first.Code != http.StatusNoContent {
+		t.Fatalf("Expected the first request through, got %d", first.Code)
+	}
+	if h := first.Header(); h.Get("X-RateLimit-Limit") != "2" || h.Get("X-RateLimit-Remaining") != "1" || h.Get("X-RateLimit-Reset") != "2" {
+		t.Errorf("Unexpected headers on an allowed request: %v", h)
+	}
+
+	serve("10.0.0.1:1235") // Same IP, different port, same bucket
+	limited := serve("10.0.0.1:1236")
+	if limited.Code != http.StatusTooManyRequests {
+		t.Fatalf("Expected 429 once the burst is spent, got %d", limited.Code)
+	}
+	if h := limited.Header(); h.Get("Retry-After") != "2" || h.Get("X-RateLimit-Remaining") != "0" || h.Get("X-RateLimit-Reset") != "4" {
+		t.Errorf("Unexpected headers on a limited request: %v", h)
+	}
+
+	if serve("10.0.0.2:1234").Code != http.StatusNoContent {
+		t.Error("Expected another IP to have its own bucket")
+	}
+	fake.Advance(2 * time.Second)
+	if serve("10.0.0.1:1237").Code != http.StatusNoContent {
+		t.Error("Expected a token back after Retry-After")
+	}
+}
+
+func TestMiddleware_KeyFunc(t *testing.T) {
+	kl := rl.NewKeyedLimiter[string](rl.KeyedConfig{Rate: 1, Burst: 1})
+	byAPIKey := func(r *http.Request) string { return r.Header.Get("X-API-Key") }
+	handler := rl.Middleware(kl, byAPIKey)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	for i, want := range []int{http.StatusOK, http.StatusTooManyRequests} {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("X-API-Key", "alice")
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		if rec.Code != want {
+			t.Errorf("Request %d: expected %d, got %d", i+1, want, rec.Code)
+		}
+	}
+	if _, ok := kl.Stats("alice"); !ok {
+		t.Error("Expected requests to be keyed by the API key")
+	}
+}
+
+func TestMiddleware_PerKey(t *testing.T) {
+	tests := []struct {
+		name       string
+		limiter    func(*clock.Fake) rl.Limiter
+		reset      string
+		retryAfter string
+	}{
+		{"fixed", func(f *clock.Fake) rl.Limiter { return rl.NewFixedWindow(2, time.Minute, rl.WithClock(f)) }, "60", "45"},
+		{"log", func(f *clock.Fake) rl.Limiter { return rl.NewSlidingWindowLog(2, time.Minute, rl.WithClock(f)) }, "60", "45"},
+		{"counter", func(f *clock.Fake) rl.Limiter { return rl.NewSlidingWindowCounter(2, time.Minute, rl.WithClock(f)) }, "120", "75"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := fakeClock(t)
+			limiter := tt.limiter(fake)
+			shared := rl.PerKey(func(string) rl.Limiter { return limiter })
+			handler := rl.Middleware(shared, nil)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+			serve := func() *httptest.ResponseRecorder {
+				rec := httptest.NewRecorder()
+				handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+				return rec
+			}
+
+			first := serve()
+			if h := first.Header(); first.Code != http.StatusOK || h.Get("X-RateLimit-Limit") != "2" || h.Get("X-RateLimit-Remaining") != "1" || h.Get("X-RateLimit-Reset") != tt.reset {
+				t.Errorf("Unexpected response to the first request: %d %v", first.Code, h)
+			}
+			serve()
+			fake.Advance(15 * time.Second)
+			limited := serve()
+			if h := limited.Header(); limited.Code != http.StatusTooManyRequests || h.Get("Retry-After") != tt.retryAfter || h.Get("X-RateLimit-Remaining") != "0" {
+				t.Errorf("Expected a 429 with Retry-After %s, got %d %v", tt.retryAfter, limited.Code, h)
+			}
+		})
+	}
+}
+
+func TestTransport_RetriesAfter429(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if string(body) != "order=42" {
+			t.Errorf("Expected the body on every attempt, got %q", body)
+		}
+		if calls.Add(1) < 3 {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	client := &http.Client{Transport: rl.NewTransport(nil, rl.NewTokenBucket(rl.Inf, 1))}
+	resp, err := client.Post(server.URL, "text/plain", strings.NewReader("order=42"))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || calls.Load() != 3 {
+		t.Errorf("Expected 200 on the third call, got %d after %d calls", resp.StatusCode, calls.Load())
+	}
+}
+
+func TestTransport_HonorsRetryAfter(t *testing.T) {
+	fake := fakeClock(t)
+	var calls atomic.Int32
+	base := roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		if calls.Add(1) == 1 {
+			return tooManyRequests("30"), nil
+		}
+		return okResponse(), nil
+	})
+	transport := rl.NewTransport(base, nil, rl.WithClock(fake))
+
+	done := make(chan *http.Response, 1)
+	go func() {
+		req, _ := http.NewRequest(http.MethodGet, "http://upstream/", nil)
+		resp, _ := transport.RoundTrip(req)
+		done <- resp
+	}()
+
+	fake.BlockUntil(1)
+	fake.Advance(29 * time.Second)
+	select {
+	case <-done:
+		t.Fatal("Expected the retry to wait out Retry-After")
+	case <-time.After(10 * time.Millisecond):
+	}
+	fake.Advance(time.Second)
+	if resp := <-done; resp == nil || resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected 200 after waiting, got %v", resp)
+	}
+	if calls.Load() != 2 {
+		t.Errorf("Expected 2 calls, got %d", calls.Load())
+	}
+}
+
+func TestTransport_GivesUp(t *testing.T) {
+	var calls atomic.Int32
+	base := roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		calls.Add(1)
+		return tooManyRequests("3600"), nil
+	})
+
+	// An hour is more than we'll sit out, the 429 comes straight back
+	transport := rl.NewTransport(base, nil)
+	req, _ := http.NewRequest(http.MethodGet, "http://upstream/", nil)
+	resp, err := transport.RoundTrip(req)
+	if err != nil || resp.StatusCode != http.StatusTooManyRequests || calls.Load() != 1 {
+		t.Errorf("Expected the 429 back after one call, got %v, %v after %d calls", resp, err, calls.Load())
+	}
+
+	// Out of retries, the last 429 comes back
+	calls.Store(0)
+	transport = &rl.Transport{Base: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		calls.Add(1)
+		return tooManyRequests("0"), nil
+	}), MaxRetries: 2}
+	resp, _ = transport.RoundTrip(req)
+	if resp.StatusCode != http.StatusTooManyRequests || calls.Load() != 3 {
+		t.Errorf("Expected 3 calls and a 429, got %d and %d", calls.Load(), resp.StatusCode)
+	}
+}
+
+func TestTransport_WaitsOnLimiter(t *testing.T) {
+	fake := fakeClock(t)
+	var calls atomic.Int32
+	base := roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		calls.Add(1)
+		return okResponse(), nil
+	})
+	transport := rl.NewTransport(base, rl.NewFixedWindow(1, time.Minute, rl.WithClock(fake)), rl.WithClock(fake))
+
+	req, _ := http.NewRequest(http.MethodGet, "http://upstream/", nil)
+	if _, err := transport.RoundTrip(req); err != nil {
+		t.Fatalf("Expected the first request through, got %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		_, err := transport.RoundTrip(req.WithContext(ctx))
+		done <- err
+	}()
+	fake.BlockUntil(1)
+	cancel()
+	if err := <-done; err != context.Canceled {
+		t.Errorf("Expected the wait to end with the context, got %v", err)
+	}
+	if calls.Load() != 1 {
+		t.Errorf("Expected the second request never to go out, got %d calls", calls.Load())
+	}
+}
+
+func TestMiddleware_HeadersUnderEviction(t *testing.T) {
+	// One key at a time, so every other request evicts someone
+	kl := rl.NewKeyedLimiter[string](rl.KeyedConfig{Rate: rl.Every(time.Hour), Burst: 1, MaxKeys: 1})
+	handler := rl.Middleware(kl, func(r *http.Request) string { return r.Header.Get("X-Key") })(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 200; j++ {
+				req := httptest.NewRequest(http.MethodGet, "/", nil)
+				req.Header.Set("X-Key", []string{"a", "b"}[(i+j)%2])
+				rec := httptest.NewRecorder()
+				handler.ServeHTTP(rec, req)
+				if rec.Code != http.StatusTooManyRequests {
+					continue
+				}
+				if h := rec.Header(); h.Get("X-RateLimit-Remaining") != "0" || h.Get("Retry-After") == "" {
+					t.Errorf("Expected a 429 to describe the bucket that denied it, got %v", h)
+					return
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+}
+
+type closeTracker struct {
+	io.Reader
+	closed atomic.Bool
+}
+
+func (c *closeTracker) Close() error {
+	c.closed.Store(true)
+	return nil
+}
+
+func TestTransport_ClosesBodyWhenNotSent(t *testing.T) {
+	base := roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		t.Error("Expected the request never to go out")
+		return okResponse(), nil
+	})
+	// A window that never has room
+	transport := rl.NewTransport(base, rl.NewFixedWindow(0, time.Minute))
+
+	body := &closeTracker{Reader: strings.NewReader("payload")}
+	req, _ := http.NewRequest(http.MethodPost, "http://upstream/", body)
+	if _, err := transport.RoundTrip(req); err == nil {
+		t.Fatal("Expected the limiter to refuse the request")
+	}
+	if !body.closed.Load() {
+		t.Error("Expected the body to be closed even though it was never sent")
+	}
+}
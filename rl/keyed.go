@@ -0,0 +1,239 @@
+package rl
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+
+	"github.com/theHamdiz/it/clock"
+)
+
+// KeyedConfig says how each key gets limited and how many keys we remember
+type KeyedConfig struct {
+	// Every key gets its own TokenBucket with this rate and burst
+	Rate  Limit
+	Burst int
+	// At most this many keys are tracked, the least recently seen one
+	// makes room. Defaults to 10000.
+	MaxKeys int
+	// Keys idle for this long are forgotten, and start over with a full
+	// bucket if they come back. Zero keeps them until MaxKeys pushes them out.
+	TTL time.Duration
+}
+
+// KeyStats is how one key has been doing since we started tracking it
+type KeyStats struct {
+	Allowed  uint64
+	Denied   uint64
+	Created  time.Time
+	LastSeen time.Time
+	// Tokens left in the key's bucket
+	Tokens float64
+}
+
+// KeyedLimiter gives every key (API key, client IP, tenant) a bucket of
+// its own, created on first sight. Idle keys are evicted on the way, no
+// janitor goroutine required, so memory stays bounded by MaxKeys.
+type KeyedLimiter[K comparable] struct {
+	config KeyedConfig
+	clock  clock.Clock
+
+	mu      sync.Mutex
+	entries map[K]*list.Element
+	// most recently seen at the front
+	lru     *list.List
+	evicted uint64
+}
+
+// keyedEntry is one key's bucket and bookkeeping
+type keyedEntry[K comparable] struct {
+	key    K
+	bucket *TokenBucket
+	stats  KeyStats
+}
+
+const defaultMaxKeys = 10000
+
+// NewKeyedLimiter creates a limiter handing out per-key buckets
+func NewKeyedLimiter[K comparable](config KeyedConfig, opts ...RateLimiterOption) *KeyedLimiter[K] {
+	if config.MaxKeys <= 0 {
+		config.MaxKeys = defaultMaxKeys
+	}
+	return &KeyedLimiter[K]{
+		config:  config,
+		clock:   applyOptions(opts).clock,
+		entries: make(map[K]*list.Element),
+		lru:     list.New(),
+	}
+}
+
+// Allow takes a token from key's bucket if there's one right now
+func (kl *KeyedLimiter[K]) Allow(key K) bool {
+	return kl.AllowN(key, 1)
+}
+
+// AllowN takes n tokens from key's bucket if they're all there right now
+func (kl *KeyedLimiter[K]) AllowN(key K, n int) bool {
+	kl.mu.Lock()
+	defer kl.mu.Unlock()
+	e := kl.entry(key)
+	allowed := e.bucket.AllowN(n)
+	e.count(allowed)
+	return allowed
+}
+
+// Reserve books a token from key's bucket, see TokenBucket.Reserve
+func (kl *KeyedLimiter[K]) Reserve(key K) *Reservation {
+	kl.mu.Lock()
+	defer kl.mu.Unlock()
+	e := kl.entry(key)
+	r := e.bucket.Reserve()
+	e.count(r.OK())
+	return r
+}
+
+// Wait blocks until a token from key's bucket is ours
+func (kl *KeyedLimiter[K]) Wait(ctx context.Context, key K) error {
+	return kl.WaitN(ctx, key, 1)
+}
+
+// WaitN blocks until n tokens from key's bucket are ours, see TokenBucket.WaitN
+func (kl *KeyedLimiter[K]) WaitN(ctx context.Context, key K, n int) error {
+	kl.mu.Lock()
+	e := kl.entry(key)
+	kl.mu.Unlock()
+
+	err := e.bucket.WaitN(ctx, n)
+
+	kl.mu.Lock()
+	defer kl.mu.Unlock()
+	e.count(err == nil)
+	return err
+}
+
+// Limiter returns key's bucket, creating it if need be
+func (kl *KeyedLimiter[K]) Limiter(key K) *TokenBucket {
+	kl.mu.Lock()
+	defer kl.mu.Unlock()
+	return kl.entry(key).bucket
+}
+
+// Stats returns how key has been doing, false if we're not tracking it
+func (kl *KeyedLimiter[K]) Stats(key K) (KeyStats, bool) {
+	kl.mu.Lock()
+	defer kl.mu.Unlock()
+	kl.expire(kl.clock.Now())
+	el, ok := kl.entries[key]
+	if !ok {
+		return KeyStats{}, false
+	}
+	e := el.Value.(*keyedEntry[K])
+	stats := e.stats
+	stats.Tokens = e.bucket.Tokens()
+	return stats, true
+}
+
+// AllStats returns the stats of every tracked key
+func (kl *KeyedLimiter[K]) AllStats() map[K]KeyStats {
+	kl.mu.Lock()
+	defer kl.mu.Unlock()
+	kl.expire(kl.clock.Now())
+	all := make(map[K]KeyStats, len(kl.entries))
+	for key, el := range kl.entries {
+		e := el.Value.(*keyedEntry[K])
+		stats := e.stats
+		stats.Tokens = e.bucket.Tokens()
+		all[key] = stats
+	}
+	return all
+}
+
+// Remove forgets key, its next request starts with a full bucket
+func (kl *KeyedLimiter[K]) Remove(key K) {
+	kl.mu.Lock()
+	defer kl.mu.Unlock()
+	if el, ok := kl.entries[key]; ok {
+		kl.lru.Remove(el)
+		delete(kl.entries, key)
+	}
+}
+
+// Len returns how many keys are tracked
+func (kl *KeyedLimiter[K]) Len() int {
+	kl.mu.Lock()
+	defer kl.mu.Unlock()
+	kl.expire(kl.clock.Now())
+	return len(kl.entries)
+}
+
+// Evicted returns how many keys were pushed out by MaxKeys or TTL so far
+func (kl *KeyedLimiter[K]) Evicted() uint64 {
+	kl.mu.Lock()
+	defer kl.mu.Unlock()
+	return kl.evicted
+}
+
+// entry finds or creates key's entry and marks it as just seen, kl.mu must be held
+func (kl *KeyedLimiter[K]) entry(key K) *keyedEntry[K] {
+	now := kl.clock.Now()
+	kl.expire(now)
+
+	if el, ok := kl.entries[key]; ok {
+		kl.lru.MoveToFront(el)
+		e := el.Value.(*keyedEntry[K])
+		e.stats.LastSeen = now
+		return e
+	}
+
+	for len(kl.entries) >= kl.config.MaxKeys {
+		kl.evict(kl.lru.Back())
+	}
+	e := &keyedEntry[K]{
+		key:    key,
+		bucket: NewTokenBucket(kl.config.Rate, kl.config.Burst, WithClock(kl.clock)),
+		stats:  KeyStats{Created: now, LastSeen: now},
+	}
+	kl.entries[key] = kl.lru.PushFront(e)
+	return e
+}
+
+// allowWithStatus takes a token from key's bucket and reads where it stands
+// under one kl.mu hold, so the headers describe the bucket that made the call
+func (kl *KeyedLimiter[K]) allowWithStatus(key K) (bool, limitStatus) {
+	kl.mu.Lock()
+	defer kl.mu.Unlock()
+	e := kl.entry(key)
+	allowed, status := e.bucket.allowWithStatus()
+	e.count(allowed)
+	return allowed, status
+}
+
+// expire drops keys idle for longer than the TTL, oldest first, kl.mu must be held
+func (kl *KeyedLimiter[K]) expire(now time.Time) {
+	if kl.config.TTL <= 0 {
+		return
+	}
+	for el := kl.lru.Back(); el != nil; el = kl.lru.Back() {
+		if now.Sub(el.Value.(*keyedEntry[K]).stats.LastSeen) < kl.config.TTL {
+			return
+		}
+		kl.evict(el)
+	}
+}
+
+// evict drops one entry, kl.mu must be held
+func (kl *KeyedLimiter[K]) evict(el *list.Element) {
+	e := kl.lru.Remove(el).(*keyedEntry[K])
+	delete(kl.entries, e.key)
+	kl.evicted++
+}
+
+// count tallies one decision
+func (e *keyedEntry[K]) count(allowed bool) {
+	if allowed {
+		e.stats.Allowed++
+	} else {
+		e.stats.Denied++
+	}
+}
@@ -0,0 +1,115 @@
+package rl_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/theHamdiz/it/rl"
+)
+
+func TestKeyedLimiter_KeysAreIndependent(t *testing.T) {
+	kl := rl.NewKeyedLimiter[string](rl.KeyedConfig{Rate: 1, Burst: 2}, rl.WithClock(fakeClock(t)))
+
+	if !kl.Allow("alice") || !kl.Allow("alice") || kl.Allow("alice") {
+		t.Error("Expected alice to get exactly her burst of 2")
+	}
+	if !kl.Allow("bob") {
+		t.Error("Expected bob not to pay for alice's enthusiasm")
+	}
+
+	stats, ok := kl.Stats("alice")
+	if !ok || stats.Allowed != 2 || stats.Denied != 1 || stats.Tokens != 0 {
+		t.Errorf("Unexpected stats for alice: %+v", stats)
+	}
+	if _, ok := kl.Stats("carol"); ok {
+		t.Error("Expected no stats for a key never seen")
+	}
+	if len(kl.AllStats()) != 2 {
+		t.Errorf("Expected 2 keys in AllStats, got %d", len(kl.AllStats()))
+	}
+}
+
+func TestKeyedLimiter_LRU(t *testing.T) {
+	kl := rl.NewKeyedLimiter[string](rl.KeyedConfig{Rate: 1, Burst: 1, MaxKeys: 2}, rl.WithClock(fakeClock(t)))
+	kl.Allow("a")
+	kl.Allow("b")
+	kl.Allow("a") // a is now the most recent
+	kl.Allow("c") // so b makes room
+
+	if kl.Len() != 2 || kl.Evicted() != 1 {
+		t.Fatalf("Expected 2 keys and 1 eviction, got %d and %d", kl.Len(), kl.Evicted())
+	}
+	if _, ok := kl.Stats("b"); ok {
+		t.Error("Expected the least recently seen key to go")
+	}
+	if _, ok := kl.Stats("a"); !ok {
+		t.Error("Expected the recently seen key to stay")
+	}
+}
+
+func TestKeyedLimiter_TTL(t *testing.T) {
+	fake := fakeClock(t)
+	kl := rl.NewKeyedLimiter[string](rl.KeyedConfig{Rate: rl.Every(time.Hour), Burst: 1, TTL: time.Minute}, rl.WithClock(fake))
+	kl.Allow("idle")
+	kl.Allow("busy")
+
+	fake.Advance(50 * time.Second)
+	kl.Allow("busy")
+	fake.Advance(20 * time.Second)
+
+	if _, ok := kl.Stats("idle"); ok {
+		t.Error("Expected the idle key to expire")
+	}
+	if _, ok := kl.Stats("busy"); !ok {
+		t.Error("Expected the busy key to stay")
+	}
+	// A returning key starts over with a full bucket
+	if !kl.Allow("idle") {
+		t.Error("Expected an expired key to start fresh")
+	}
+}
+
+func TestKeyedLimiter_WaitAndReserve(t *testing.T) {
+	fake := fakeClock(t)
+	kl := rl.NewKeyedLimiter[string](rl.KeyedConfig{Rate: 10, Burst: 1}, rl.WithClock(fake))
+	if r := kl.Reserve("k"); !r.OK() || r.Delay() != 0 {
+		t.Fatal("Expected an immediate reservation")
+	}
+
+	done := make(chan error, 1)
+	go func() { done <- kl.Wait(context.Background(), "k") }()
+	fake.BlockUntil(1)
+	fake.Advance(100 * time.Millisecond)
+	if err := <-done; err != nil {
+		t.Fatalf("Expected Wait to succeed, got %v", err)
+	}
+
+	if stats, _ := kl.Stats("k"); stats.Allowed != 2 {
+		t.Errorf("Expected 2 allowed, got %+v", stats)
+	}
+	if kl.Limiter("k").Burst() != 1 {
+		t.Error("Expected Limiter to return the key's bucket")
+	}
+	kl.Remove("k")
+	if kl.Len() != 0 {
+		t.Error("Expected Remove to forget the key")
+	}
+}
+
+func TestKeyedLimiter_Concurrent(t *testing.T) {
+	kl := rl.NewKeyedLimiter[int](rl.KeyedConfig{Rate: 1, Burst: 5, MaxKeys: 8})
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			kl.Allow(i % 16)
+		}(i)
+	}
+	wg.Wait()
+	if kl.Len() > 8 {
+		t.Errorf("Expected at most 8 keys, got %d", kl.Len())
+	}
+}
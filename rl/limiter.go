@@ -0,0 +1,68 @@
+package rl
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/theHamdiz/it/clock"
+)
+
+// Limiter is what every limiter in here can do. Code that takes a Limiter
+// doesn't care whether there's a bucket, a window or a bouncer behind it,
+// so swapping algorithms is a one-line change at construction time.
+type Limiter interface {
+	// Allow reports whether one event may go right now, and counts it if so
+	Allow() bool
+	// Wait blocks until one event may go, or ctx is done
+	Wait(ctx context.Context) error
+	// Execute runs operation once it may go
+	Execute(ctx context.Context, operation func() error) error
+}
+
+// Make sure nobody falls out of line
+var (
+	_ Limiter = (*RateLimiter)(nil)
+	_ Limiter = (*TokenBucket)(nil)
+	_ Limiter = (*FixedWindow)(nil)
+	_ Limiter = (*SlidingWindowLog)(nil)
+	_ Limiter = (*SlidingWindowCounter)(nil)
+)
+
+// waitTurn keeps asking take until it says yes, sleeping on clk for as long
+// as take says the next chance is away. A wait of zero or less alongside a
+// no means there will never be a next chance. Windows can be snatched by
+// someone else in the meantime, so waking up isn't a yes, it's a retry.
+func waitTurn(ctx context.Context, clk clock.Clock, limit int, take func() (bool, time.Duration)) error {
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		ok, wait := take()
+		if ok {
+			return nil
+		}
+		if wait <= 0 {
+			return fmt.Errorf("%w: limit is %d", ErrExceedsBurst, limit)
+		}
+		if deadline, ok := ctx.Deadline(); ok && clk.Until(deadline) < wait {
+			return ErrWaitExceedsDeadline
+		}
+
+		timer := clk.NewTimer(wait)
+		select {
+		case <-timer.C():
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		}
+	}
+}
+
+// execute is Execute for anything with a Wait
+func execute(ctx context.Context, limiter Limiter, operation func() error) error {
+	if err := limiter.Wait(ctx); err != nil {
+		return err
+	}
+	return operation()
+}
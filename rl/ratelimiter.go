@@ -4,6 +4,8 @@ package rl
 import (
 	"context"
 	"time"
+
+	"github.com/theHamdiz/it/clock"
 )
 
 // RateLimiter is like a bouncer for your function calls
@@ -14,12 +16,29 @@ type RateLimiter struct {
 	batchSize int                // How many get in at once
 	ctx       context.Context    // The party's context
 	cancel    context.CancelFunc // The panic button
+	clock     clock.Clock        // Who we ask what time it is
+}
+
+// RateLimiterOption tweaks any of our limiters before the party starts
+type RateLimiterOption func(*limiterOptions)
+
+// limiterOptions is what the options get to tweak
+type limiterOptions struct {
+	clock clock.Clock
+}
+
+// WithClock has a limiter refill, count windows and wait on c. Works for
+// every limiter in here, and a KeyedLimiter hands it down to its buckets.
+func WithClock(c clock.Clock) RateLimiterOption {
+	return func(o *limiterOptions) {
+		o.clock = clock.Or(c)
+	}
 }
 
 // NewRateLimiter creates a new function traffic controller
 // interval: how often we hand out passes
 // batchSize: how many passes we give out at once
-func NewRateLimiter(interval time.Duration, batchSize int) *RateLimiter {
+func NewRateLimiter(interval time.Duration, batchSize int, opts ...RateLimiterOption) *RateLimiter {
 	ctx, cancel := context.WithCancel(context.Background())
 	rl := &RateLimiter{
 		tokens:    make(chan struct{}, batchSize), // The VIP list
@@ -27,6 +46,7 @@ func NewRateLimiter(interval time.Duration, batchSize int) *RateLimiter {
 		batchSize: batchSize,
 		ctx:       ctx,
 		cancel:    cancel,
+		clock:     applyOptions(opts).clock,
 	}
 
 	go rl.replenishTokens() // Start the token fairy
@@ -34,13 +54,14 @@ func NewRateLimiter(interval time.Duration, batchSize int) *RateLimiter {
 }
 
 // NewRateLimiterWithContext is like NewRateLimiter but with a bedtime
-func NewRateLimiterWithContext(ctx context.Context, interval time.Duration, batchSize int) *RateLimiter {
+func NewRateLimiterWithContext(ctx context.Context, interval time.Duration, batchSize int, opts ...RateLimiterOption) *RateLimiter {
 	rl := &RateLimiter{
 		tokens:    make(chan struct{}, batchSize),
 		interval:  interval,
 		batchSize: batchSize,
 		ctx:       ctx,
 		cancel:    func() {}, // Fake cancel because we're using someone else's context
+		clock:     applyOptions(opts).clock,
 	}
 
 	go rl.replenishTokens()
@@ -50,35 +71,52 @@ func NewRateLimiterWithContext(ctx context.Context, interval time.Duration, batc
 // Execute runs your function when it's allowed to
 // Return an error when your function misbehaves
 func (rl *RateLimiter) Execute(ctx context.Context, operation func() error) error {
+	if err := rl.Wait(ctx); err != nil {
+		return err // Sorry, party's over
+	}
+	return operation() // Your turn to shine
+}
+
+// Allow grabs a pass if one's lying around, without queueing for it
+func (rl *RateLimiter) Allow() bool {
 	select {
-	case <-ctx.Done():
-		return ctx.Err() // Sorry, party's over
 	case <-rl.tokens:
-		return operation() // Your turn to shine
+		return true
+	default:
+		return false
 	}
 }
 
-// ExecuteRateLimited is like Execute but for functions that actually return something
-func ExecuteRateLimited[T any](rl *RateLimiter, ctx context.Context, operation func() (T, error)) (T, error) {
-	var zero T // In case we need to leave empty-handed
+// Wait queues for a pass until one shows up or ctx gives up
+func (rl *RateLimiter) Wait(ctx context.Context) error {
 	select {
 	case <-ctx.Done():
-		return zero, ctx.Err()
+		return ctx.Err()
 	case <-rl.tokens:
-		return operation()
+		return nil
+	}
+}
+
+// ExecuteRateLimited is like Execute but for functions that actually return something
+// Any Limiter will do, so switching algorithms doesn't mean touching call sites
+func ExecuteRateLimited[T any](limiter Limiter, ctx context.Context, operation func() (T, error)) (T, error) {
+	var zero T // In case we need to leave empty-handed
+	if err := limiter.Wait(ctx); err != nil {
+		return zero, err
 	}
+	return operation()
 }
 
 // replenishTokens is the backstage worker keeping the party supplied
 func (rl *RateLimiter) replenishTokens() {
-	ticker := time.NewTicker(rl.interval)
+	ticker := rl.clock.NewTicker(rl.interval)
 	defer ticker.Stop()
 
 	for {
 		select {
 		case <-rl.ctx.Done():
 			return // Time to go home
-		case <-ticker.C:
+		case <-ticker.C():
 			for i := 0; i < rl.batchSize; i++ {
 				select {
 				case rl.tokens <- struct{}{}:
@@ -127,3 +165,12 @@ func (rl *RateLimiter) BatchSize() int {
 func (rl *RateLimiter) Ctx() context.Context {
 	return rl.ctx
 }
+
+// applyOptions collects opts over the defaults
+func applyOptions(opts []RateLimiterOption) limiterOptions {
+	o := limiterOptions{clock: clock.Real()}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
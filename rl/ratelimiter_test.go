@@ -6,6 +6,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/theHamdiz/it/clock"
 	"github.com/theHamdiz/it/rl"
 )
 
@@ -192,3 +193,49 @@ func TestDefaultRateLimiterWithContext(t *testing.T) {
 		t.Errorf("Expected rate limiter to use provided context")
 	}
 }
+
+// TestRateLimiter_FakeClock hands out passes only when the fake clock ticks
+func TestRateLimiter_FakeClock(t *testing.T) {
+	fake := clock.NewFake(time.Now())
+	rl_ := rl.NewRateLimiter(time.Minute, 2, rl.WithClock(fake))
+	defer rl_.Close()
+
+	// Wait for the token fairy to start watching the clock
+	fake.BlockUntil(1)
+	if len(rl_.Tokens()) != 0 {
+		t.Fatal("Expected no passes before the first tick")
+	}
+
+	fake.Advance(time.Minute)
+	deadline := time.Now().Add(time.Second)
+	for len(rl_.Tokens()) < 2 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if len(rl_.Tokens()) != 2 {
+		t.Errorf("Expected a batch of 2 passes after one tick, got %d", len(rl_.Tokens()))
+	}
+}
+
+// TestRateLimiter_AllowAndWait grabs passes without queueing, then queues for one
+func TestRateLimiter_AllowAndWait(t *testing.T) {
+	fake := fakeClock(t)
+	rl_ := rl.NewRateLimiter(time.Minute, 1, rl.WithClock(fake))
+	defer rl_.Close()
+
+	fake.BlockUntil(1)
+	if rl_.Allow() {
+		t.Fatal("Expected no pass before the first tick")
+	}
+
+	done := make(chan error, 1)
+	go func() { done <- rl_.Wait(context.Background()) }()
+	fake.Advance(time.Minute)
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("Expected Wait to get a pass, got %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Expected Wait to return after a tick")
+	}
+}
@@ -0,0 +1,267 @@
+package rl
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/theHamdiz/it/clock"
+)
+
+// Limit is a rate in events per second, fractions welcome
+type Limit float64
+
+// TokenBucket is the rate limiter that can answer "may I go now?". Tokens
+// trickle in at the limit and pile up to the burst; every event takes one.
+// The refill is worked out from the clock whenever someone asks, so there
+// is no goroutine ticking away in the background, and nothing to Close.
+type TokenBucket struct {
+	mu     sync.Mutex
+	limit  Limit
+	burst  int
+	tokens float64
+	// when tokens was last brought up to date
+	last  time.Time
+	clock clock.Clock
+}
+
+// Reservation is a promise of tokens at some point, maybe now
+type Reservation struct {
+	ok     bool
+	bucket *TokenBucket
+	tokens int
+	// when the tokens are ours to use
+	timeToAct time.Time
+}
+
+// Inf lets everything through, no tokens needed
+const Inf = Limit(math.MaxFloat64)
+
+var (
+	// ErrExceedsBurst means the request is bigger than the bucket will ever hold
+	ErrExceedsBurst = errors.New("rl: request exceeds burst")
+	// ErrWaitExceedsDeadline means the tokens would arrive after ctx's deadline
+	ErrWaitExceedsDeadline = errors.New("rl: wait would exceed context deadline")
+)
+
+// Every turns "one event per interval" into a Limit
+func Every(interval time.Duration) Limit {
+	if interval <= 0 {
+		return Inf
+	}
+	return 1 / Limit(interval.Seconds())
+}
+
+// NewTokenBucket creates a bucket refilling at rate tokens per second and
+// holding up to burst of them. It starts full.
+func NewTokenBucket(rate Limit, burst int, opts ...RateLimiterOption) *TokenBucket {
+	o := applyOptions(opts)
+	return &TokenBucket{
+		limit:  rate,
+		burst:  burst,
+		tokens: float64(burst),
+		last:   o.clock.Now(),
+		clock:  o.clock,
+	}
+}
+
+// Allow takes a token if there's one right now
+func (tb *TokenBucket) Allow() bool {
+	return tb.AllowN(1)
+}
+
+// AllowN takes n tokens if they're all there right now, or none at all
+func (tb *TokenBucket) AllowN(n int) bool {
+	tb.mu.Lock()
+	defer tb.mu.Unlock()
+	return tb.allowN(n)
+}
+
+// Reserve books a token whether or not it's there yet; Delay says how
+// long until it is. Cancel the reservation if you decide not to wait.
+func (tb *TokenBucket) Reserve() *Reservation {
+	return tb.ReserveN(1)
+}
+
+// ReserveN books n tokens, see Reserve. Asking for more than the burst, or
+// for anything at all from a zero rate with an empty bucket, can never work
+// out; those reservations aren't OK.
+func (tb *TokenBucket) ReserveN(n int) *Reservation {
+	tb.mu.Lock()
+	defer tb.mu.Unlock()
+	now := tb.clock.Now()
+	tb.advance(now)
+
+	if tb.limit == Inf {
+		return &Reservation{ok: true, bucket: tb, timeToAct: now}
+	}
+	if n > tb.burst {
+		return &Reservation{bucket: tb, tokens: n}
+	}
+
+	tokens := tb.tokens - float64(n)
+	var wait time.Duration
+	if tokens < 0 {
+		if tb.limit <= 0 {
+			return &Reservation{bucket: tb, tokens: n}
+		}
+		wait = tokensToDuration(-tokens, tb.limit)
+	}
+	tb.tokens = tokens
+	return &Reservation{ok: true, bucket: tb, tokens: n, timeToAct: now.Add(wait)}
+}
+
+// Wait blocks until a token is ours, see WaitN
+func (tb *TokenBucket) Wait(ctx context.Context) error {
+	return tb.WaitN(ctx, 1)
+}
+
+// WaitN blocks until n tokens are ours, ctx is done, or it's clear they
+// won't arrive before ctx's deadline
+func (tb *TokenBucket) WaitN(ctx context.Context, n int) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	r := tb.ReserveN(n)
+	if !r.ok {
+		return fmt.Errorf("%w: asked for %d, burst is %d", ErrExceedsBurst, n, tb.Burst())
+	}
+
+	delay := r.Delay()
+	if delay <= 0 {
+		return nil
+	}
+	if deadline, ok := ctx.Deadline(); ok && tb.clock.Until(deadline) < delay {
+		r.Cancel()
+		return ErrWaitExceedsDeadline
+	}
+
+	timer := tb.clock.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C():
+		return nil
+	case <-ctx.Done():
+		r.Cancel()
+		return ctx.Err()
+	}
+}
+
+// Execute runs operation once a token is ours
+func (tb *TokenBucket) Execute(ctx context.Context, operation func() error) error {
+	return execute(ctx, tb, operation)
+}
+
+// SetRate changes the refill rate from now on. Tokens already earned stay.
+func (tb *TokenBucket) SetRate(rate Limit) {
+	tb.mu.Lock()
+	defer tb.mu.Unlock()
+	tb.advance(tb.clock.Now())
+	tb.limit = rate
+}
+
+// SetBurst changes how many tokens the bucket holds, spilling any extra
+func (tb *TokenBucket) SetBurst(burst int) {
+	tb.mu.Lock()
+	defer tb.mu.Unlock()
+	tb.advance(tb.clock.Now())
+	tb.burst = burst
+	if tb.tokens > float64(burst) {
+		tb.tokens = float64(burst)
+	}
+}
+
+// Rate returns the refill rate in tokens per second
+func (tb *TokenBucket) Rate() Limit {
+	tb.mu.Lock()
+	defer tb.mu.Unlock()
+	return tb.limit
+}
+
+// Burst returns how many tokens the bucket holds at most
+func (tb *TokenBucket) Burst() int {
+	tb.mu.Lock()
+	defer tb.mu.Unlock()
+	return tb.burst
+}
+
+// Tokens returns how many tokens are there right now, negative while
+// reservations are waiting on the refill
+func (tb *TokenBucket) Tokens() float64 {
+	tb.mu.Lock()
+	defer tb.mu.Unlock()
+	tb.advance(tb.clock.Now())
+	return tb.tokens
+}
+
+// OK reports whether the reservation can ever be honored
+func (r *Reservation) OK() bool {
+	return r.ok
+}
+
+// Delay returns how long until the reserved tokens are there, zero if they
+// already are. Reservations that aren't OK never get there.
+func (r *Reservation) Delay() time.Duration {
+	if !r.ok {
+		return time.Duration(math.MaxInt64)
+	}
+	if delay := r.bucket.clock.Until(r.timeToAct); delay > 0 {
+		return delay
+	}
+	return 0
+}
+
+// Cancel hands the tokens back if their time hasn't come yet
+func (r *Reservation) Cancel() {
+	if !r.ok || r.tokens == 0 {
+		return
+	}
+	tb := r.bucket
+	tb.mu.Lock()
+	defer tb.mu.Unlock()
+	now := tb.clock.Now()
+	if !now.Before(r.timeToAct) {
+		return
+	}
+	tb.advance(now)
+	tb.tokens = math.Min(tb.tokens+float64(r.tokens), float64(tb.burst))
+	r.tokens = 0
+}
+
+// allowN is AllowN, tb.mu must be held
+func (tb *TokenBucket) allowN(n int) bool {
+	tb.advance(tb.clock.Now())
+	if tb.limit == Inf {
+		return true
+	}
+	if tb.tokens < float64(n) {
+		return false
+	}
+	tb.tokens -= float64(n)
+	return true
+}
+
+// advance adds what trickled in since last time, tb.mu must be held
+func (tb *TokenBucket) advance(now time.Time) {
+	elapsed := now.Sub(tb.last)
+	if elapsed <= 0 {
+		return
+	}
+	tb.last = now
+	if tb.limit == Inf || tb.limit <= 0 {
+		return
+	}
+	tb.tokens = math.Min(tb.tokens+elapsed.Seconds()*float64(tb.limit), float64(tb.burst))
+}
+
+// tokensToDuration is how long it takes rate to produce tokens
+func tokensToDuration(tokens float64, rate Limit) time.Duration {
+	seconds := tokens / float64(rate)
+	if seconds*float64(time.Second) >= math.MaxInt64 {
+		return time.Duration(math.MaxInt64)
+	}
+	return time.Duration(math.Ceil(seconds * float64(time.Second)))
+}
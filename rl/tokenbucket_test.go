@@ -0,0 +1,169 @@
+package rl_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/theHamdiz/it/clock"
+	"github.com/theHamdiz/it/rl"
+)
+
+func fakeClock(t *testing.T) *clock.Fake {
+	t.Helper()
+	return clock.NewFake(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+}
+
+func TestTokenBucket_AllowAndBurst(t *testing.T) {
+	fake := fakeClock(t)
+	tb := rl.NewTokenBucket(2.5, 3, rl.WithClock(fake))
+
+	for i := 0; i < 3; i++ {
+		if !tb.Allow() {
+			t.Fatalf("Expected the burst of 3 to be allowed, denied #%d", i+1)
+		}
+	}
+	if tb.Allow() {
+		t.Fatal("Expected an empty bucket to say no")
+	}
+
+	// 2.5/s means a token every 400ms
+	fake.Advance(399 * time.Millisecond)
+	if tb.Allow() {
+		t.Error("Expected no token before 400ms")
+	}
+	fake.Advance(time.Millisecond)
+	if !tb.Allow() {
+		t.Error("Expected a token at 400ms")
+	}
+
+	// A long nap refills to the burst, no further
+	fake.Advance(time.Hour)
+	if got := tb.Tokens(); got != 3 {
+		t.Errorf("Expected the bucket capped at 3, got %v", got)
+	}
+}
+
+func TestTokenBucket_AllowNIsAllOrNothing(t *testing.T) {
+	tb := rl.NewTokenBucket(1, 5, rl.WithClock(fakeClock(t)))
+	if tb.AllowN(6) {
+		t.Error("Expected more than the burst to be denied")
+	}
+	if !tb.AllowN(4) || tb.AllowN(2) || !tb.AllowN(1) {
+		t.Error("Expected 4 then a denied 2 then the last 1")
+	}
+}
+
+func TestTokenBucket_Reserve(t *testing.T) {
+	fake := fakeClock(t)
+	tb := rl.NewTokenBucket(10, 1, rl.WithClock(fake))
+	if r := tb.Reserve(); !r.OK() || r.Delay() != 0 {
+		t.Fatalf("Expected an immediate reservation, got delay %v", r.Delay())
+	}
+
+	r := tb.Reserve()
+	if !r.OK() || r.Delay() != 100*time.Millisecond {
+		t.Fatalf("Expected to wait 100ms at 10/s, got %v", r.Delay())
+	}
+	next := tb.Reserve()
+	if next.Delay() != 200*time.Millisecond {
+		t.Errorf("Expected reservations to queue up, got %v", next.Delay())
+	}
+
+	next.Cancel()
+	fake.Advance(100 * time.Millisecond)
+	if r.Delay() != 0 {
+		t.Errorf("Expected the first reservation to be due, got %v", r.Delay())
+	}
+	if tb.Allow() {
+		t.Error("Expected the first reservation to have used the refill")
+	}
+
+	if tb.ReserveN(2).OK() {
+		t.Error("Expected a reservation bigger than the burst to be refused")
+	}
+}
+
+func TestTokenBucket_Wait(t *testing.T) {
+	fake := fakeClock(t)
+	tb := rl.NewTokenBucket(1, 2, rl.WithClock(fake))
+	ctx := context.Background()
+
+	if err := tb.WaitN(ctx, 2); err != nil {
+		t.Fatalf("Expected the burst right away, got %v", err)
+	}
+	if err := tb.WaitN(ctx, 3); !errors.Is(err, rl.ErrExceedsBurst) {
+		t.Errorf("Expected ErrExceedsBurst, got %v", err)
+	}
+
+	done := make(chan error, 1)
+	go func() { done <- tb.WaitN(ctx, 2) }()
+	fake.BlockUntil(1)
+	fake.Advance(2 * time.Second)
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("Expected the wait to succeed, got %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Expected Wait to return once the clock moved")
+	}
+}
+
+func TestTokenBucket_WaitRespectsContext(t *testing.T) {
+	fake := fakeClock(t)
+	tb := rl.NewTokenBucket(1, 1, rl.WithClock(fake))
+	tb.Allow()
+
+	ctx, cancel := clock.WithTimeout(context.Background(), fake, time.Millisecond)
+	defer cancel()
+	if err := tb.WaitN(ctx, 1); !errors.Is(err, rl.ErrWaitExceedsDeadline) {
+		t.Errorf("Expected a 1s wait not to fit in 1ms, got %v", err)
+	}
+	if tb.Tokens() != 0 {
+		t.Errorf("Expected the refused wait to give its token back, got %v", tb.Tokens())
+	}
+
+	ctx, cancel = context.WithCancel(context.Background())
+	cancel()
+	if err := tb.WaitN(ctx, 1); !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected Canceled, got %v", err)
+	}
+}
+
+func TestTokenBucket_SetRateAndBurst(t *testing.T) {
+	fake := fakeClock(t)
+	tb := rl.NewTokenBucket(1, 10, rl.WithClock(fake))
+	tb.AllowN(10)
+
+	fake.Advance(time.Second)
+	tb.SetRate(100)
+	if got := tb.Tokens(); got != 1 {
+		t.Errorf("Expected tokens earned at the old rate to stay, got %v", got)
+	}
+	fake.Advance(50 * time.Millisecond)
+	if got := tb.Tokens(); got != 6 {
+		t.Errorf("Expected 5 more at 100/s, got %v", got)
+	}
+
+	tb.SetBurst(4)
+	if got := tb.Tokens(); got != 4 || tb.Burst() != 4 {
+		t.Errorf("Expected the extra tokens spilled, got %v", got)
+	}
+	if tb.Rate() != 100 {
+		t.Errorf("Expected rate 100, got %v", tb.Rate())
+	}
+}
+
+func TestTokenBucket_InfAndEvery(t *testing.T) {
+	tb := rl.NewTokenBucket(rl.Inf, 0, rl.WithClock(fakeClock(t)))
+	for i := 0; i < 1000; i++ {
+		if !tb.Allow() {
+			t.Fatal("Expected Inf to allow everything")
+		}
+	}
+	if got := rl.Every(250 * time.Millisecond); got != 4 {
+		t.Errorf("Expected Every(250ms) to be 4/s, got %v", got)
+	}
+}
@@ -0,0 +1,228 @@
+package rl
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/theHamdiz/it/clock"
+)
+
+// windowBase is what all the window limiters have in common
+type windowBase struct {
+	mu     sync.Mutex
+	limit  int
+	window time.Duration
+	clock  clock.Clock
+}
+
+// FixedWindow allows limit events per window, windows lined up on the
+// clock: "1000 per hour" means 1000 between 14:00 and 15:00. Cheap as it
+// gets, at the price of letting 2x through around the turn of the hour.
+type FixedWindow struct {
+	windowBase
+	start time.Time
+	count int
+}
+
+// SlidingWindowLog allows limit events in any window-long stretch of time,
+// exactly, by remembering when each of the last limit events happened.
+// Precise, and it pays for it with memory that grows with the limit.
+type SlidingWindowLog struct {
+	windowBase
+	// ring of the timestamps still in the window, oldest at head
+	log   []time.Time
+	head  int
+	count int
+}
+
+// SlidingWindowCounter allows about limit events in any window-long stretch
+// of time. It keeps two fixed-window counts and weighs the previous one by
+// how much of it the sliding window still covers: two integers worth of
+// memory, no 2x bursts at window edges, a little guesswork in between.
+type SlidingWindowCounter struct {
+	windowBase
+	start    time.Time
+	previous int
+	current  int
+}
+
+// NewFixedWindow creates a limiter allowing limit events per window
+func NewFixedWindow(limit int, window time.Duration, opts ...RateLimiterOption) *FixedWindow {
+	return &FixedWindow{windowBase: newWindowBase(limit, window, opts)}
+}
+
+// NewSlidingWindowLog creates a limiter allowing limit events in any window
+func NewSlidingWindowLog(limit int, window time.Duration, opts ...RateLimiterOption) *SlidingWindowLog {
+	return &SlidingWindowLog{
+		windowBase: newWindowBase(limit, window, opts),
+		log:        make([]time.Time, max(limit, 0)),
+	}
+}
+
+// NewSlidingWindowCounter creates a limiter allowing about limit events in any window
+func NewSlidingWindowCounter(limit int, window time.Duration, opts ...RateLimiterOption) *SlidingWindowCounter {
+	return &SlidingWindowCounter{windowBase: newWindowBase(limit, window, opts)}
+}
+
+// Limit returns how many events a window allows
+func (w *windowBase) Limit() int {
+	return w.limit
+}
+
+// Window returns how long a window is
+func (w *windowBase) Window() time.Duration {
+	return w.window
+}
+
+// Allow counts an event if the current window has room for it
+func (fw *FixedWindow) Allow() bool {
+	ok, _ := fw.take()
+	return ok
+}
+
+// Wait blocks until the current window, or a later one, has room
+func (fw *FixedWindow) Wait(ctx context.Context) error {
+	return waitTurn(ctx, fw.clock, fw.limit, fw.take)
+}
+
+// Execute runs operation once a window has room for it
+func (fw *FixedWindow) Execute(ctx context.Context, operation func() error) error {
+	return execute(ctx, fw, operation)
+}
+
+// Allow counts an event if the last window has room for it
+func (sl *SlidingWindowLog) Allow() bool {
+	ok, _ := sl.take()
+	return ok
+}
+
+// Wait blocks until the oldest event in the window slides out of it
+func (sl *SlidingWindowLog) Wait(ctx context.Context) error {
+	return waitTurn(ctx, sl.clock, sl.limit, sl.take)
+}
+
+// Execute runs operation once the window has room for it
+func (sl *SlidingWindowLog) Execute(ctx context.Context, operation func() error) error {
+	return execute(ctx, sl, operation)
+}
+
+// Allow counts an event if the estimated window has room for it
+func (sc *SlidingWindowCounter) Allow() bool {
+	ok, _ := sc.take()
+	return ok
+}
+
+// Wait blocks until the estimate drops enough to make room
+func (sc *SlidingWindowCounter) Wait(ctx context.Context) error {
+	return waitTurn(ctx, sc.clock, sc.limit, sc.take)
+}
+
+// Execute runs operation once the window has room for it
+func (sc *SlidingWindowCounter) Execute(ctx context.Context, operation func() error) error {
+	return execute(ctx, sc, operation)
+}
+
+// newWindowBase sets up the common parts, a window has to have some length
+func newWindowBase(limit int, window time.Duration, opts []RateLimiterOption) windowBase {
+	if window <= 0 {
+		panic("rl: non-positive window")
+	}
+	return windowBase{limit: limit, window: window, clock: applyOptions(opts).clock}
+}
+
+// take counts an event if there's room, otherwise says how long until the next window
+func (fw *FixedWindow) take() (bool, time.Duration) {
+	fw.mu.Lock()
+	defer fw.mu.Unlock()
+	return fw.takeAt(fw.clock.Now())
+}
+
+// takeAt is take as of now, fw.mu must be held
+func (fw *FixedWindow) takeAt(now time.Time) (bool, time.Duration) {
+	if start := now.Truncate(fw.window); !start.Equal(fw.start) {
+		fw.start, fw.count = start, 0
+	}
+	if fw.limit <= 0 {
+		return false, 0
+	}
+	if fw.count < fw.limit {
+		fw.count++
+		return true, 0
+	}
+	return false, fw.start.Add(fw.window).Sub(now)
+}
+
+// take counts an event if there's room, otherwise says how long until the
+// oldest one in the window drops out
+func (sl *SlidingWindowLog) take() (bool, time.Duration) {
+	sl.mu.Lock()
+	defer sl.mu.Unlock()
+	return sl.takeAt(sl.clock.Now())
+}
+
+// takeAt is take as of now, sl.mu must be held
+func (sl *SlidingWindowLog) takeAt(now time.Time) (bool, time.Duration) {
+	if sl.limit <= 0 {
+		return false, 0
+	}
+	cutoff := now.Add(-sl.window)
+	for sl.count > 0 && !sl.log[sl.head].After(cutoff) {
+		sl.head = (sl.head + 1) % sl.limit
+		sl.count--
+	}
+	if sl.count < sl.limit {
+		sl.log[(sl.head+sl.count)%sl.limit] = now
+		sl.count++
+		return true, 0
+	}
+	return false, sl.log[sl.head].Add(sl.window).Sub(now)
+}
+
+// take counts an event if the estimate leaves room, otherwise works out
+// when the weight of the previous window will have dropped enough
+func (sc *SlidingWindowCounter) take() (bool, time.Duration) {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	return sc.takeAt(sc.clock.Now())
+}
+
+// takeAt is take as of now, sc.mu must be held
+func (sc *SlidingWindowCounter) takeAt(now time.Time) (bool, time.Duration) {
+	switch start := now.Truncate(sc.window); {
+	case start.Equal(sc.start):
+	case start.Equal(sc.start.Add(sc.window)):
+		sc.start, sc.previous, sc.current = start, sc.current, 0
+	default:
+		sc.start, sc.previous, sc.current = start, 0, 0
+	}
+	if sc.limit <= 0 {
+		return false, 0
+	}
+
+	elapsed := now.Sub(sc.start)
+	weight := 1 - float64(elapsed)/float64(sc.window)
+	if float64(sc.previous)*weight+float64(sc.current)+1 <= float64(sc.limit) {
+		sc.current++
+		return true, 0
+	}
+
+	// Room left in this window once enough of the previous one slides away
+	room := float64(sc.limit - 1 - sc.current)
+	if room >= 0 {
+		due := sc.share(1 - room/float64(sc.previous))
+		return false, max(due-elapsed, 1)
+	}
+	// Otherwise the current window becomes the previous one, and the same goes
+	due := sc.share(1 - float64(sc.limit-1)/float64(sc.current))
+	return false, max(sc.window+due-elapsed, 1)
+}
+
+// share is the given fraction of the window, rounded up
+func (sc *SlidingWindowCounter) share(fraction float64) time.Duration {
+	if fraction <= 0 {
+		return 0
+	}
+	return time.Duration(math.Ceil(fraction * float64(sc.window)))
+}
@@ -0,0 +1,171 @@
+package rl_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/theHamdiz/it/clock"
+	"github.com/theHamdiz/it/rl"
+)
+
+func allowed(l rl.Limiter, n int) int {
+	count := 0
+	for i := 0; i < n; i++ {
+		if l.Allow() {
+			count++
+		}
+	}
+	return count
+}
+
+func TestFixedWindow(t *testing.T) {
+	fake := fakeClock(t)
+	fw := rl.NewFixedWindow(3, time.Minute, rl.WithClock(fake))
+
+	fake.Advance(50 * time.Second)
+	if got := allowed(fw, 5); got != 3 {
+		t.Fatalf("Expected 3 allowed in the first window, got %d", got)
+	}
+	// Windows are lined up on the clock, the next one starts at 1m
+	fake.Advance(10 * time.Second)
+	if got := allowed(fw, 5); got != 3 {
+		t.Errorf("Expected a fresh window at the minute, got %d allowed", got)
+	}
+	if fw.Limit() != 3 || fw.Window() != time.Minute {
+		t.Error("Expected Limit and Window to be what we asked for")
+	}
+}
+
+func TestSlidingWindowLog(t *testing.T) {
+	fake := fakeClock(t)
+	sl := rl.NewSlidingWindowLog(3, time.Minute, rl.WithClock(fake))
+
+	sl.Allow()
+	fake.Advance(20 * time.Second)
+	sl.Allow()
+	sl.Allow()
+	if sl.Allow() {
+		t.Fatal("Expected the 4th event within a minute to be denied")
+	}
+
+	// The first event drops out at 60s, the others hang on until 80s
+	fake.Advance(40 * time.Second)
+	if got := allowed(sl, 3); got != 1 {
+		t.Errorf("Expected exactly one slot to free up at 60s, got %d", got)
+	}
+	fake.Advance(20 * time.Second)
+	if got := allowed(sl, 3); got != 2 {
+		t.Errorf("Expected two slots to free up at 80s, got %d", got)
+	}
+}
+
+func TestSlidingWindowCounter(t *testing.T) {
+	fake := fakeClock(t)
+	sc := rl.NewSlidingWindowCounter(10, time.Minute, rl.WithClock(fake))
+
+	if got := allowed(sc, 15); got != 10 {
+		t.Fatalf("Expected 10 allowed in the first window, got %d", got)
+	}
+
+	// A quarter into the next window, 75% of the previous 10 still counts
+	fake.Advance(75 * time.Second)
+	if got := allowed(sc, 15); got != 2 {
+		t.Errorf("Expected 2 allowed at a quarter in, got %d", got)
+	}
+
+	// Two windows of silence forget everything
+	fake.Advance(3 * time.Minute)
+	if got := allowed(sc, 15); got != 10 {
+		t.Errorf("Expected a clean slate after a long nap, got %d", got)
+	}
+}
+
+func TestWindowLimiters_Wait(t *testing.T) {
+	limiters := map[string]func(*clock.Fake) rl.Limiter{
+		"fixed":   func(f *clock.Fake) rl.Limiter { return rl.NewFixedWindow(1, time.Minute, rl.WithClock(f)) },
+		"log":     func(f *clock.Fake) rl.Limiter { return rl.NewSlidingWindowLog(1, time.Minute, rl.WithClock(f)) },
+		"counter": func(f *clock.Fake) rl.Limiter { return rl.NewSlidingWindowCounter(1, time.Minute, rl.WithClock(f)) },
+	}
+
+	for name, newLimiter := range limiters {
+		t.Run(name, func(t *testing.T) {
+			fake := fakeClock(t)
+			l := newLimiter(fake)
+			if err := l.Wait(context.Background()); err != nil {
+				t.Fatalf("Expected the first Wait to go straight through, got %v", err)
+			}
+
+			done := make(chan error, 1)
+			go func() { done <- l.Wait(context.Background()) }()
+			fake.BlockUntil(1)
+			select {
+			case <-done:
+				t.Fatal("Expected Wait to block while the window is full")
+			default:
+			}
+			for i := 0; i < 4; i++ {
+				fake.Advance(30 * time.Second)
+				select {
+				case err := <-done:
+					if err != nil {
+						t.Fatalf("Expected Wait to succeed, got %v", err)
+					}
+					return
+				case <-time.After(10 * time.Millisecond):
+				}
+			}
+			t.Fatal("Expected Wait to return within two windows")
+		})
+	}
+}
+
+func TestWindowLimiters_WaitErrors(t *testing.T) {
+	fake := fakeClock(t)
+
+	zero := rl.NewFixedWindow(0, time.Minute, rl.WithClock(fake))
+	if err := zero.Wait(context.Background()); !errors.Is(err, rl.ErrExceedsBurst) {
+		t.Errorf("Expected ErrExceedsBurst from a zero limit, got %v", err)
+	}
+
+	sl := rl.NewSlidingWindowLog(1, time.Minute, rl.WithClock(fake))
+	sl.Allow()
+	ctx, cancel := clock.WithTimeout(context.Background(), fake, time.Second)
+	defer cancel()
+	if err := sl.Wait(ctx); !errors.Is(err, rl.ErrWaitExceedsDeadline) {
+		t.Errorf("Expected ErrWaitExceedsDeadline, got %v", err)
+	}
+
+	cancelled, cancelNow := context.WithCancel(context.Background())
+	cancelNow()
+	if err := sl.Execute(cancelled, func() error { return nil }); !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled, got %v", err)
+	}
+}
+
+func TestLimiter_SwapAlgorithms(t *testing.T) {
+	fake := fakeClock(t)
+	limiters := []rl.Limiter{
+		rl.NewTokenBucket(1, 2, rl.WithClock(fake)),
+		rl.NewFixedWindow(2, time.Minute, rl.WithClock(fake)),
+		rl.NewSlidingWindowLog(2, time.Minute, rl.WithClock(fake)),
+		rl.NewSlidingWindowCounter(2, time.Minute, rl.WithClock(fake)),
+	}
+
+	for _, l := range limiters {
+		// Same call site, whatever the algorithm
+		got, err := rl.ExecuteRateLimited(l, context.Background(), func() (string, error) {
+			return "through", nil
+		})
+		if err != nil || got != "through" {
+			t.Errorf("%T: expected ExecuteRateLimited to go through, got %q, %v", l, got, err)
+		}
+		if err := l.Execute(context.Background(), func() error { return nil }); err != nil {
+			t.Errorf("%T: expected Execute to go through, got %v", l, err)
+		}
+		if l.Allow() {
+			t.Errorf("%T: expected the limit of 2 to be used up", l)
+		}
+	}
+}
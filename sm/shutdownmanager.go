@@ -4,13 +4,17 @@ package sm
 import (
 	"context"
 	"fmt"
-	"log"
 	"os"
 	"os/signal"
 	"syscall"
 	"time"
+
+	"github.com/theHamdiz/it/logger"
 )
 
+// Our last words, filed under "sm" so they can be silenced separately
+var log = logger.Named("sm")
+
 // ShutdownAction is like a todo list for your program's last moments
 type ShutdownAction struct {
 	Name     string                      // What we're trying to clean up
@@ -76,7 +80,7 @@ func (sm *ShutdownManager) Start() {
 
 		select {
 		case <-sigChan:
-			log.Println("Received shutdown signal. Time for the long goodbye...")
+			log.Info("Received shutdown signal. Time for the long goodbye...")
 			if err := sm.executeAll(); err != nil {
 				sm.errChan <- err // One last disappointment
 			}
@@ -91,7 +95,7 @@ func (sm *ShutdownManager) Start() {
 // Like a todo list, but with more panic
 func (sm *ShutdownManager) executeAll() error {
 	for _, action := range sm.actions {
-		log.Printf("Executing last wishes: %s", action.Name)
+		log.Infof("Executing last wishes: %s", action.Name)
 
 		actionCtx, cancel := context.WithTimeout(sm.ctx, action.Timeout)
 		err := action.Action(actionCtx)
@@ -101,7 +105,7 @@ func (sm *ShutdownManager) executeAll() error {
 			if action.Critical {
 				return fmt.Errorf("critical shutdown action %s failed: %w", action.Name, err)
 			}
-			log.Printf("Non-critical shutdown action %s failed: %v", action.Name, err)
+			log.Warnf("Non-critical shutdown action %s failed: %v", action.Name, err)
 		}
 	}
 	return nil
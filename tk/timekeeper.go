@@ -4,9 +4,13 @@ import (
 	"sync"
 	"time"
 
+	"github.com/theHamdiz/it/clock"
 	logger2 "github.com/theHamdiz/it/logger"
 )
 
+// Everything tk says goes through here, so it can be turned up or down on its own
+var log = logger2.Named("tk")
+
 // TimeKeeper tracks execution time because time is money,
 // and we're all about that ROI
 type TimeKeeper struct {
@@ -14,6 +18,7 @@ type TimeKeeper struct {
 	name     string
 	logger   *logger2.Logger
 	callback func(duration time.Duration)
+	clock    clock.Clock
 }
 
 // NewTimeKeeper creates a new timekeeper because someone has to
@@ -21,7 +26,8 @@ type TimeKeeper struct {
 func NewTimeKeeper(name string, opts ...TimeKeeperOption) *TimeKeeper {
 	tk := &TimeKeeper{
 		name:   name,
-		logger: logger2.DefaultLogger(),
+		logger: log,
+		clock:  clock.Real(),
 	}
 	for _, opt := range opts {
 		opt(tk)
@@ -39,16 +45,24 @@ func WithCallback(cb func(duration time.Duration)) TimeKeeperOption {
 	}
 }
 
+// WithClock swaps the wall clock for another one, because a fake clock
+// makes every operation take exactly as long as the test says
+func WithClock(c clock.Clock) TimeKeeperOption {
+	return func(tk *TimeKeeper) {
+		tk.clock = clock.Or(c)
+	}
+}
+
 // Start begins timing because every journey begins with a single step
 func (tk *TimeKeeper) Start() *TimeKeeper {
-	tk.start = time.Now()
+	tk.start = tk.clock.Now()
 	return tk
 }
 
 // Stop ends timing and logs the duration because all good things
 // must come to an end
 func (tk *TimeKeeper) Stop() time.Duration {
-	duration := time.Since(tk.start)
+	duration := tk.clock.Since(tk.start)
 	tk.logger.Infof("⏱️ %s took %v", tk.name, duration)
 	if tk.callback != nil {
 		tk.callback(duration)
@@ -75,9 +89,9 @@ type AsyncTimeKeeper struct {
 
 // NewAsyncTimeKeeper creates a new async timekeeper because
 // concurrent timing needs special handling
-func NewAsyncTimeKeeper(name string) *AsyncTimeKeeper {
+func NewAsyncTimeKeeper(name string, opts ...TimeKeeperOption) *AsyncTimeKeeper {
 	return &AsyncTimeKeeper{
-		timekeeper: NewTimeKeeper(name),
+		timekeeper: NewTimeKeeper(name, opts...),
 	}
 }
 
@@ -85,11 +99,12 @@ func NewAsyncTimeKeeper(name string) *AsyncTimeKeeper {
 // parallel operations is like herding cats
 func (atk *AsyncTimeKeeper) Track(fn func()) {
 	atk.wg.Add(1)
-	start := time.Now()
+	clk := atk.timekeeper.clock
+	start := clk.Now()
 
 	go func() {
 		defer func() {
-			duration := time.Since(start)
+			duration := clk.Since(start)
 			atk.mu.Lock()
 			atk.durations = append(atk.durations, duration)
 			atk.mu.Unlock()
@@ -5,6 +5,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/theHamdiz/it/clock"
 	"github.com/theHamdiz/it/tk"
 )
 
@@ -107,3 +108,14 @@ func TestAsyncTimeKeeperParallel(t *testing.T) {
 		}
 	}
 }
+
+// TestTimeKeeper_FakeClock makes an operation take exactly as long as we say
+func TestTimeKeeper_FakeClock(t *testing.T) {
+	fake := clock.NewFake(time.Now())
+	keeper := tk.NewTimeKeeper("fake", tk.WithClock(fake)).Start()
+	fake.Advance(3 * time.Second)
+
+	if d := keeper.Stop(); d != 3*time.Second {
+		t.Errorf("Expected exactly 3s, got %v", d)
+	}
+}